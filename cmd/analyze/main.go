@@ -0,0 +1,165 @@
+// cmd/analyze：打印某个局面的静态评估拆解，以及每个走法走完后的分数和主要贡献项。
+//
+//	go run ./cmd/analyze -replay games.json -game 0 -ply 30
+//	go run ./cmd/analyze -csv dataset.csv -row 1234
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+
+	"hexxagon_go/internal/game"
+)
+
+// 回放文件的格式与 cmd/hexxagon/replay 相同
+type match struct {
+	Winner string `json:"winner"`
+	Steps  []struct {
+		Move game.Move `json:"move"`
+	} `json:"steps"`
+}
+
+func main() {
+	replayPath := flag.String("replay", "", "回放 JSON（对局数组，每步一个 move）")
+	gameIdx := flag.Int("game", 0, "-replay 里第几局")
+	ply := flag.Int("ply", 0, "-replay 里走完前几步后的局面")
+	csvPath := flag.String("csv", "", "selfplay 写出的数据集 CSV")
+	row := flag.Int("row", 0, "-csv 里第几行（从 0 数，行里的张量是行棋方视角）")
+	weightsPath := flag.String("weights", "", "静态评估权重文件（空则默认权重）")
+	top := flag.Int("top", 3, "分数最高的几个走法打印完整拆解")
+	flag.Parse()
+
+	w := game.DefaultEvalWeights()
+	if *weightsPath != "" {
+		var err error
+		if w, err = game.LoadEvalWeights(*weightsPath); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	var (
+		b    *game.Board
+		side game.CellState
+		err  error
+	)
+	switch {
+	case *replayPath != "":
+		b, side, err = fromReplay(*replayPath, *gameIdx, *ply)
+	case *csvPath != "":
+		b, side, err = fromCSV(*csvPath, *row)
+	default:
+		log.Fatal("需要 -replay 或 -csv")
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("行棋方 %s，A %d 子，B %d 子，空 %d\n\n", sideName(side),
+		b.CountPieces(game.PlayerA), b.CountPieces(game.PlayerB), b.CountPieces(game.Empty))
+	fmt.Print(w.Explain(b, side))
+
+	type line struct {
+		mv game.Move
+		ex game.EvalExplanation
+	}
+	var lines []line
+	for _, mv := range game.GenerateMoves(b, side) {
+		_, undo := mv.MakeMove(b, side)
+		lines = append(lines, line{mv, w.Explain(b, side)})
+		b.UnmakeMove(undo)
+	}
+	if len(lines) == 0 {
+		fmt.Println("\n没有可走的着法")
+		return
+	}
+	sort.SliceStable(lines, func(i, j int) bool { return lines[i].ex.Total > lines[j].ex.Total })
+
+	fmt.Printf("\n%d 个走法（走完后 %s 视角的静态分）:\n", len(lines), sideName(side))
+	for _, l := range lines {
+		fmt.Printf("%-22s %5d ", moveString(l.mv), l.ex.Total)
+		for _, t := range l.ex.Terms {
+			if t.Contribution != 0 {
+				fmt.Printf(" %s=%+d", t.Name, t.Contribution)
+			}
+		}
+		fmt.Println()
+	}
+	for i := 0; i < *top && i < len(lines); i++ {
+		fmt.Printf("\n#%d %s\n%s", i+1, moveString(lines[i].mv), lines[i].ex)
+	}
+}
+
+func fromReplay(path string, gi, ply int) (*game.Board, game.CellState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	var matches []match
+	if err := json.Unmarshal(data, &matches); err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", path, err)
+	}
+	if gi < 0 || gi >= len(matches) {
+		return nil, 0, fmt.Errorf("%s 只有 %d 局", path, len(matches))
+	}
+	steps := matches[gi].Steps
+	if ply < 0 || ply > len(steps) {
+		return nil, 0, fmt.Errorf("第 %d 局只有 %d 步", gi, len(steps))
+	}
+	st := game.NewGameState(4)
+	for i := 0; i < ply; i++ {
+		if _, _, err := st.MakeMove(steps[i].Move); err != nil {
+			return nil, 0, fmt.Errorf("第 %d 步 %s: %w", i, moveString(steps[i].Move), err)
+		}
+	}
+	return st.Board, st.CurrentPlayer, nil
+}
+
+// fromCSV 张量不带行棋方，按 A 解码（各项都是行棋方视角，结果与真实行棋方一致）
+func fromCSV(path string, n int) (*game.Board, game.CellState, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+	rd := csv.NewReader(f)
+	rd.FieldsPerRecord = -1
+	for i := 0; ; i++ {
+		rec, err := rd.Read()
+		if err == io.EOF {
+			return nil, 0, fmt.Errorf("%s 只有 %d 行", path, i)
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		if i < n {
+			continue
+		}
+		if len(rec) < game.TensorLen {
+			return nil, 0, fmt.Errorf("第 %d 行只有 %d 列", n, len(rec))
+		}
+		t := make([]float32, game.TensorLen)
+		for j := range t {
+			if rec[j] != "0" {
+				t[j] = 1
+			}
+		}
+		return game.DecodeBoardTensor(t, game.PlayerA), game.PlayerA, nil
+	}
+}
+
+func sideName(p game.CellState) string {
+	if p == game.PlayerA {
+		return "A"
+	}
+	return "B"
+}
+
+func moveString(m game.Move) string {
+	return fmt.Sprintf("(%d,%d)->(%d,%d)", m.From.Q, m.From.R, m.To.Q, m.To.R)
+}
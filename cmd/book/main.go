@@ -0,0 +1,250 @@
+// cmd/book：开局库工具。从自对弈记录建库、合并多台机器的库、把库导出成文本。
+//
+//	go run ./cmd/book build -out book.bin -maxply 16 dataset.csv [replays.json ...]
+//	go run ./cmd/book merge -out all.bin a.bin b.bin
+//	go run ./cmd/book dump -top 50 book.bin
+//
+// 之后 selfplay 用 -book book.bin 即可。
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"hexxagon_go/internal/game"
+)
+
+// CSV 每行：0..242 张量（行棋方视角）| 243 走法索引 | 244 z（A 方视角的胜负）| 245 对局号
+const (
+	colMove = game.TensorLen
+	colZ    = game.TensorLen + 1
+	colGame = game.TensorLen + 2
+	numCols = game.TensorLen + 3
+)
+
+// 回放文件的格式与 cmd/hexxagon/replay 相同
+type match struct {
+	Winner string `json:"winner"`
+	Steps  []struct {
+		Move game.Move `json:"move"`
+	} `json:"steps"`
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	args := os.Args[2:]
+	switch os.Args[1] {
+	case "build":
+		build(args)
+	case "merge":
+		merge(args)
+	case "dump":
+		dump(args)
+	default:
+		usage()
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "用法: book build|merge|dump [参数] 文件...")
+	os.Exit(2)
+}
+
+func build(args []string) {
+	fs := flag.NewFlagSet("build", flag.ExitOnError)
+	outPath := fs.String("out", "book.bin", "写出的开局库")
+	maxPly := fs.Int("maxply", 16, "每局只记前这么多手")
+	ply0 := fs.Int("ply0", 4, "CSV 第一行是第几手（selfplay 不带 -book 时开头随机走 4 手，不进 CSV）")
+	minGames := fs.Int("min", 1, "对局数少于它的走法不进库")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		log.Fatal("用法: book build [参数] dataset.csv|replays.json ...")
+	}
+
+	bk := game.NewBook()
+	for _, path := range fs.Args() {
+		var (
+			n   int
+			err error
+		)
+		if strings.EqualFold(filepath.Ext(path), ".json") {
+			n, err = addReplays(bk, path, *maxPly)
+		} else {
+			n, err = addCSV(bk, path, *ply0, *maxPly)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("%s: %d 局", path, n)
+	}
+	save(bk, *outPath, *minGames)
+}
+
+func merge(args []string) {
+	fs := flag.NewFlagSet("merge", flag.ExitOnError)
+	outPath := fs.String("out", "book.bin", "合并后的开局库")
+	minGames := fs.Int("min", 1, "合并后对局数少于它的走法删掉")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		log.Fatal("用法: book merge -out all.bin a.bin b.bin ...")
+	}
+	bk := game.NewBook()
+	for _, path := range fs.Args() {
+		o, err := game.LoadBook(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("%s: %d 个局面", path, o.Len())
+		bk.Merge(o)
+	}
+	save(bk, *outPath, *minGames)
+}
+
+func dump(args []string) {
+	fs := flag.NewFlagSet("dump", flag.ExitOnError)
+	top := fs.Int("top", 0, "只打印前这么多个局面（按手数排，0=全部）")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		log.Fatal("用法: book dump [-top N] book.bin")
+	}
+	bk, err := game.LoadBook(fs.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	w := bufio.NewWriter(os.Stdout)
+	defer w.Flush()
+	fmt.Fprintf(w, "%d 个局面（走法为规范朝向）\n", bk.Len())
+	n := 0
+	bk.Each(func(p game.BookPosition) {
+		if *top > 0 && n >= *top {
+			return
+		}
+		n++
+		fmt.Fprintf(w, "\n%016x  第 %d 手\n", p.Key, p.Ply)
+		for _, m := range p.Moves {
+			fmt.Fprintf(w, "  %-22s %6d 局  胜 %d 和 %d 负 %d  得分 %.3f\n",
+				moveString(m.Move), m.Games(), m.Wins, m.Draws, m.Losses, m.Score())
+		}
+	})
+}
+
+func save(bk *game.Book, path string, minGames int) {
+	if minGames > 1 {
+		log.Printf("删掉 %d 个对局不足 %d 的局面", bk.Prune(minGames), minGames)
+	}
+	if err := bk.Save(path); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("开局库 %d 个局面，已写到 %s", bk.Len(), path)
+}
+
+// addReplays 回放里每局都从标准开局走起，重放一遍即可
+func addReplays(bk *game.Book, path string, maxPly int) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	var matches []match
+	if err := json.Unmarshal(data, &matches); err != nil {
+		return 0, fmt.Errorf("%s: %w", path, err)
+	}
+	n := 0
+	for i, m := range matches {
+		moves := make([]game.Move, len(m.Steps))
+		for j, st := range m.Steps {
+			moves[j] = st.Move
+		}
+		if err := bk.AddGame(moves, maxPly); err != nil {
+			log.Printf("%s 第 %d 局跳过: %v", path, i, err)
+			continue
+		}
+		n++
+	}
+	return n, nil
+}
+
+// addCSV 按对局号把连续的行归成一局；selfplay 从 A 开始轮流走，行号的奇偶就是行棋方。
+// 张量还原出局面，z 是 A 方视角的胜负
+func addCSV(bk *game.Book, path string, ply0, maxPly int) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	rd := csv.NewReader(bufio.NewReaderSize(f, 1<<20))
+	rd.FieldsPerRecord = -1
+	rd.ReuseRecord = true
+
+	var (
+		games   int
+		curID   string
+		row     int
+		tensor  = make([]float32, game.TensorLen)
+		badRows int
+	)
+	for {
+		rec, err := rd.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return games, err
+		}
+		if len(rec) != numCols {
+			badRows++
+			continue
+		}
+		if rec[colGame] != curID {
+			curID, row = rec[colGame], 0
+			games++
+		}
+		ply := ply0 + row
+		row++
+		if ply >= maxPly {
+			continue
+		}
+		z, err1 := strconv.Atoi(rec[colZ])
+		mv, err2 := strconv.Atoi(rec[colMove])
+		if err1 != nil || err2 != nil {
+			badRows++
+			continue
+		}
+		side := game.PlayerA
+		if (row-1)%2 == 1 {
+			side = game.PlayerB
+		}
+		for i := range tensor {
+			tensor[i] = 0
+			if rec[i] != "0" {
+				tensor[i] = 1
+			}
+		}
+		winner := game.Empty
+		switch {
+		case z > 0:
+			winner = game.PlayerA
+		case z < 0:
+			winner = game.PlayerB
+		}
+		bk.Add(game.DecodeBoardTensor(tensor, side), side, ply, game.PolicyIndexToMove(mv), winner)
+	}
+	if badRows > 0 {
+		log.Printf("%s: 跳过 %d 行格式不对的数据", path, badRows)
+	}
+	return games, nil
+}
+
+func moveString(m game.Move) string {
+	return fmt.Sprintf("(%d,%d)->(%d,%d)", m.From.Q, m.From.R, m.To.Q, m.To.R)
+}
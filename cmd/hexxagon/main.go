@@ -4,6 +4,7 @@ import (
 	"flag"
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/audio"
+	"hexxagon_go/internal/game"
 	"hexxagon_go/internal/ui"
 	"log"
 	"strconv"
@@ -37,8 +38,15 @@ func main() {
 	// —— 新增：启动参数 —— //
 	modeFlag := flag.String("mode", "pve", "游戏模式: pve(人机) 或 pvp(人人)")
 	scoreTipFlag := flag.String("tip", "false", "是否展示玩家棋子评分(true/false)")
+	aiFlag := flag.String("ai", "ab", "人机 AI 类型: ab(α-β 搜索) 或 mcts(蒙特卡洛树搜索)")
+	evalFlag := flag.String("eval", "", "AI 评估器: static / cnn / nnue:文件 / nnueq:文件 / linear:文件（空为默认）")
+	weightsFlag := flag.String("weights", "", "静态评估的权重文件（JSON），等同 -eval static:文件")
 	flag.Parse()
 	aiEnabled := (*modeFlag == "pve") // pve=启用 AI，pvp=禁用 AI
+	evalSpec, err := game.WithWeights(*evalFlag, *weightsFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
 	// 把 string 转成 bool
 	showScores, err := strconv.ParseBool(*scoreTipFlag)
 	if err != nil {
@@ -50,7 +58,7 @@ func main() {
 		log.Fatal("audio context not initialized")
 	}
 
-	screen, err := ui.NewGameScreen(ctx, aiEnabled, showScores) // 传入 AI 开关
+	screen, err := ui.NewGameScreen(ctx, aiEnabled, showScores, *aiFlag, evalSpec) // 传入 AI 开关、类型与评估器
 	if err != nil {
 		log.Fatal(err)
 	}
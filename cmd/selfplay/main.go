@@ -2,10 +2,10 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"encoding/csv"
 	"flag"
 	"hexxagon_go/internal/game"
-	//"hexxagon_go/internal/ml"
 	"io"
 	"log"
 	"math/rand"
@@ -21,7 +21,97 @@ func main() {
 	numGames := flag.Int("n", 50000, "目标总对局数")
 	depth := flag.Int("d", 2, "搜索深度")
 	outFile := flag.String("out", "dataset.csv", "CSV 文件")
+	ttMB := flag.Int("ttmb", 16, "每个引擎的置换表大小（MB）")
+	moveTime := flag.Duration("movetime", 0, "每步思考时间上限（0=只按深度）")
+	endgame := flag.Int("endgame", 4, "空格数不超过它时改用残局精确求解（<0 关闭）")
+	algo := flag.String("algo", "ab", "对弈算法: ab(α-β 搜索) 或 mcts(蒙特卡洛树搜索)")
+	playouts := flag.Int("playouts", 800, "mcts 每步模拟次数")
+	modelPath := flag.String("model", "", "ONNX 模型路径（空则 HEX_ONNX_PATH，再空用内嵌模型）")
+	batch := flag.Int("batch", 0, "把各 worker 的网络请求凑成最多这么多个一批推理（<=1 不凑批）")
+	sessions := flag.Int("sessions", 1, "onnxruntime 会话数（同时在跑的批数）")
+	evalSpec := flag.String("eval", "", "评估器，如 static / cnn / nnue:net.nnue / nnueq:net.nnue / linear:linear_eval.json（空: ab 用 static，mcts 用网络）")
+	evalSpecB := flag.String("evalb", "", "B 方单独用的评估器，格式同 -eval，空则与 A 方相同（含 -weights）；用来 A/B 对比")
+	weightsPath := flag.String("weights", "", "静态评估的权重文件（JSON），等同 -eval static:文件；双方都用，除非另给 -evalb")
+	saveWeights := flag.String("saveweights", "", "把当前权重（默认值或 -weights 读到的）写到该文件后退出，作为调参起点")
+	bookPath := flag.String("book", "", "开局库（cmd/book 生成）；给了就按库加权随机走开局，不再随机开局")
+	bookPly := flag.Int("bookply", 0, "只在前这么多手查库（0=库里有就查）")
+	ttCachePath := flag.String("ttcache", "", "分析缓存（cmd/ttcache 生成），每个 α-β 引擎启动时灌进置换表；评估器/搜索设置与缓存不同的一方不灌")
+	threads := flag.Int("threads", 0, "每个 α-β 引擎的 Lazy SMP 线程数（0=CPU 数 / worker 数）")
 	flag.Parse()
+	if *saveWeights != "" {
+		w := game.DefaultEvalWeights()
+		if *weightsPath != "" {
+			var err error
+			if w, err = game.LoadEvalWeights(*weightsPath); err != nil {
+				log.Fatal(err)
+			}
+		}
+		if err := w.Save(*saveWeights); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("权重已写到 %s", *saveWeights)
+		return
+	}
+	spec, err := game.WithWeights(*evalSpec, *weightsPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *algo != "ab" && *algo != "mcts" {
+		log.Fatalf("未知的 -algo %q（可选 ab / mcts）", *algo)
+	}
+	if *modelPath != "" || *batch > 1 || *sessions > 1 {
+		m, err := game.LoadModel(game.ModelOptions{Path: *modelPath, Batch: *batch, Sessions: *sessions})
+		if err != nil {
+			log.Fatalf("load model: %v", err)
+		}
+		defer m.Close()
+		game.SetDefaultModel(m)
+		if bt, ok := m.(*game.Batcher); ok {
+			defer func() {
+				st := bt.Stats()
+				log.Printf("网络请求 %d 次，%d 批，平均每批 %.1f", st.Requests, st.Batches, float64(st.Requests)/float64(max(st.Batches, 1)))
+			}()
+		}
+	}
+
+	// 评估器各方一个，引擎之间共享只读权重
+	evals := map[game.CellState]game.Evaluator{}
+	for _, side := range []struct {
+		p    game.CellState
+		name string
+		spec string
+	}{{game.PlayerA, "A", spec}, {game.PlayerB, "B", *evalSpecB}} {
+		s := side.spec
+		if s == "" {
+			s = spec // 没给 -evalb 时 B 方与 A 方相同，-weights 也一起用
+		}
+		if s == "" {
+			continue
+		}
+		ev, err := game.NewEvaluator(s)
+		if err != nil {
+			log.Fatal(err)
+		}
+		evals[side.p] = ev
+		log.Printf("%s 方评估器: %s", side.name, ev.Name())
+	}
+
+	var book *game.Book
+	if *bookPath != "" {
+		if book, err = game.LoadBook(*bookPath); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("开局库 %s: %d 个局面", *bookPath, book.Len())
+	}
+	bookOpts := game.BookOptions{MaxPly: *bookPly}
+
+	var ttCache *game.TTCache
+	if *ttCachePath != "" {
+		if ttCache, err = game.LoadTTCacheFile(*ttCachePath); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("分析缓存 %s: %d 个条目", *ttCachePath, ttCache.Len())
+	}
 
 	_ = game.AllCoords(4)
 
@@ -42,7 +132,18 @@ func main() {
 	if workers < 1 {
 		workers = 1
 	}
-	log.Printf("CPU=%d，启动 %d 个 worker 并行自对弈", runtime.NumCPU(), workers)
+	if *threads <= 0 {
+		*threads = max(runtime.NumCPU()/workers, 1)
+	}
+	log.Printf("CPU=%d，启动 %d 个 worker 并行自对弈，每个引擎 %d 线程", runtime.NumCPU(), workers, *threads)
+	if *algo == "mcts" {
+		if m, err := game.DefaultModel(); err != nil {
+			log.Printf("网络不可用，MCTS 先验用均匀分布、价值用静态评估: %v", err)
+		} else {
+			info := m.Info()
+			log.Printf("网络推理后端: %s（%s）", info.Backend, info.Source)
+		}
+	}
 
 	jobs := make(chan int, workers*2)
 	var wg sync.WaitGroup
@@ -52,9 +153,34 @@ func main() {
 		go func(workerID int) {
 			defer wg.Done()
 			r := rand.New(rand.NewSource(time.Now().UnixNano() + int64(workerID))) // 独立随机源
+			// 双方各用一个搜索器，置换表/搜索树互不共享
+			engines := make(map[game.CellState]game.Searcher, 2)
+			for _, p := range []game.CellState{game.PlayerA, game.PlayerB} {
+				if *algo == "mcts" {
+					// 根节点加噪声，让自对弈的开局更分散
+					opts := game.MCTSOptions{Playouts: *playouts, DirichletAlpha: 0.3, Seed: r.Int63()}
+					if ev := evals[p]; ev != nil {
+						opts.Value = game.EvaluatorValue(ev)
+					}
+					engines[p] = game.NewMCTS(opts)
+				} else {
+					e := game.NewEngine(game.EngineOptions{TTMB: *ttMB, EndgameEmpties: *endgame, Evaluator: evals[p], Threads: *threads})
+					if ttCache != nil {
+						// A/B 两方评估器不同时，缓存只灌得进和它设置相同的那一方
+						if _, err := e.LoadTTCache(ttCache); err != nil && workerID == 0 {
+							side := "A"
+							if p == game.PlayerB {
+								side = "B"
+							}
+							log.Printf("%s 方不用分析缓存: %v", side, err)
+						}
+					}
+					engines[p] = e
+				}
+			}
 
 			for id := range jobs { // ← 这里把 id 取出来
-				rows, ok := playOneGame(*depth, id, r) // 把 id 和随机源传进去
+				rows, ok := playOneGame(engines, *depth, *moveTime, id, r, book, bookOpts) // 把 id 和随机源传进去
 				if !ok {
 					continue
 				}
@@ -66,6 +192,13 @@ func main() {
 				w.Flush()
 				wMu.Unlock()
 			}
+			for _, s := range engines {
+				if mc, ok := s.(*game.MCTS); ok {
+					if n, err := mc.NNErrors(); n > 0 {
+						log.Printf("worker %d: 网络推理失败 %d 次，已退回静态评估，最近一次: %v", workerID, n, err)
+					}
+				}
+			}
 		}(i)
 	}
 
@@ -84,14 +217,18 @@ func main() {
 playOneGame 把“一整局合法且 50≤步数≤500 的样本”转换为 [][]string。
 
 	返回 ok=false 表示该局被丢弃（步数过短/过长）。
+	book 非 nil 时开局按库走（ab 与 mcts 都一样），否则开头随机走 2 个回合。
 */
-func playOneGame(depth int, id int, r *rand.Rand) ([][]string, bool) {
+func playOneGame(engines map[game.CellState]game.Searcher, depth int, moveTime time.Duration, id int, r *rand.Rand,
+	book *game.Book, bookOpts game.BookOptions) ([][]string, bool) {
 	const (
 		maxMoves = 500
 		minMoves = 50
 	)
 	state := game.NewGameState(4)
-	addRandomOpening(state, 2, r)
+	if book == nil {
+		addRandomOpening(state, 2, r)
+	}
 
 	player := game.PlayerA
 
@@ -102,13 +239,30 @@ func playOneGame(depth int, id int, r *rand.Rand) ([][]string, bool) {
 		if id%2 == 0 && player == game.PlayerB && depth > 1 {
 			curDepth = depth + 1 // B 方弱 1 层
 		}
-		mv, ok := game.FindBestMoveAtDepth(state.Board, player, curDepth)
+		var (
+			mv game.Move
+			ok bool
+		)
+		if book != nil {
+			mv, ok = book.Pick(state.Board, player, bookOpts, r)
+		}
+		switch e, isAB := engines[player].(*game.Engine); {
+		case ok: // 库里的着
+		case isAB && moveTime == 0:
+			mv, ok = e.FindBestMoveAtDepth(state.Board, player, curDepth)
+		default:
+			res := engines[player].Search(context.Background(), state.Board, player, game.SearchLimits{
+				MaxDepth: curDepth,
+				MoveTime: moveTime,
+			})
+			mv, ok = res.Move, res.OK
+		}
 
 		if !ok {
 			break
 		}
 		tensor := game.EncodeBoardTensor(state.Board, player)
-		mvIdx := game.AxialToIndex(mv.To)
+		mvIdx := game.MoveToPolicyIndex(mv) // 落点×18 方向，见 game.PolicyLen
 		row := make([]string, 0, game.TensorLen+3)
 		for _, v := range tensor {
 			if v == 0 {
@@ -0,0 +1,193 @@
+// cmd/ttcache：分析缓存工具。把回放里的开局/测试局面搜一遍导出成缓存、合并多台机器的缓存、查看概况。
+//
+//	go run ./cmd/ttcache build -out cache.bin -d 8 -maxply 12 replays.json
+//	go run ./cmd/ttcache merge -out all.bin a.bin b.bin
+//	go run ./cmd/ttcache info cache.bin
+//
+// 缓存里记着建缓存时的评估器和静态搜索设置，selfplay 用 -ttcache 灌回去时要一致，否则不灌。
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"time"
+
+	"hexxagon_go/internal/game"
+)
+
+// 回放文件的格式与 cmd/hexxagon/replay 相同
+type match struct {
+	Winner string `json:"winner"`
+	Steps  []struct {
+		Move game.Move `json:"move"`
+	} `json:"steps"`
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	args := os.Args[2:]
+	switch os.Args[1] {
+	case "build":
+		build(args)
+	case "merge":
+		merge(args)
+	case "info":
+		info(args)
+	default:
+		usage()
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "用法: ttcache build|merge|info [参数] 文件...")
+	os.Exit(2)
+}
+
+func build(args []string) {
+	fs := flag.NewFlagSet("build", flag.ExitOnError)
+	outPath := fs.String("out", "cache.bin", "写出的缓存")
+	inPath := fs.String("in", "", "先灌进这份已有的缓存，在它基础上接着搜")
+	depth := fs.Int("d", 8, "每个局面的搜索深度")
+	moveTime := fs.Duration("movetime", 0, "每个局面的时间上限（0=只按深度）")
+	maxPly := fs.Int("maxply", 12, "每局只搜前这么多手的局面")
+	ttMB := fs.Int("ttmb", 256, "置换表大小（MB）")
+	evalSpec := fs.String("eval", "", "评估器，格式同 selfplay -eval（空为 static）")
+	minDepth := fs.Int("min", 2, "深度不足它的条目不导出")
+	exact := fs.Bool("exact", false, "只导出精确值")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		log.Fatal("用法: ttcache build [参数] replays.json ...")
+	}
+
+	opts := game.EngineOptions{TTMB: *ttMB}
+	if *evalSpec != "" {
+		ev, err := game.NewEvaluator(*evalSpec)
+		if err != nil {
+			log.Fatal(err)
+		}
+		opts.Evaluator = ev
+	}
+	e := game.NewEngine(opts)
+	var in *game.TTCache
+	if *inPath != "" {
+		c, err := game.LoadTTCacheFile(*inPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if _, err := e.LoadTTCache(c); err != nil {
+			log.Fatalf("%s: %v", *inPath, err)
+		}
+		log.Printf("%s: %d 个条目", *inPath, c.Len())
+		in = c
+	}
+
+	// 同一局面在多局里重复出现只搜一次
+	seen := make(map[uint64]bool)
+	n := 0
+	start := time.Now()
+	for _, path := range fs.Args() {
+		matches, err := loadReplays(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for gi, m := range matches {
+			st := game.NewGameState(4)
+			for ply := 0; ply < *maxPly && ply <= len(m.Steps) && !st.GameOver; ply++ {
+				if key := game.CanonicalKey(st.Board, st.CurrentPlayer); !seen[key] {
+					seen[key] = true
+					e.Search(context.Background(), st.Board, st.CurrentPlayer,
+						game.SearchLimits{MaxDepth: *depth, MoveTime: *moveTime})
+					n++
+				}
+				if ply == len(m.Steps) {
+					break
+				}
+				if _, _, err := st.MakeMove(m.Steps[ply].Move); err != nil {
+					log.Printf("%s 第 %d 局第 %d 步: %v", path, gi, ply, err)
+					break
+				}
+			}
+		}
+		log.Printf("%s: %d 局，累计 %d 个局面，用时 %v", path, len(matches), n, time.Since(start).Round(time.Second))
+	}
+
+	c := e.ExportTT(game.TTCacheOptions{MinDepth: *minDepth, ExactOnly: *exact})
+	if in != nil {
+		// 表里被挤掉的旧条目不能丢
+		if err := c.Merge(in); err != nil {
+			log.Fatal(err)
+		}
+	}
+	save(c, *outPath)
+}
+
+func merge(args []string) {
+	fs := flag.NewFlagSet("merge", flag.ExitOnError)
+	outPath := fs.String("out", "cache.bin", "合并后的缓存")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		log.Fatal("用法: ttcache merge -out all.bin a.bin b.bin ...")
+	}
+	c := game.NewTTCache()
+	for _, path := range fs.Args() {
+		o, err := game.LoadTTCacheFile(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("%s: %d 个条目", path, o.Len())
+		if err := c.Merge(o); err != nil {
+			log.Fatalf("%s: %v", path, err)
+		}
+	}
+	save(c, *outPath)
+}
+
+func info(args []string) {
+	fs := flag.NewFlagSet("info", flag.ExitOnError)
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		log.Fatal("用法: ttcache info cache.bin")
+	}
+	c, err := game.LoadTTCacheFile(fs.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	s := c.Settings()
+	fmt.Printf("%d 个条目，评估器 %s，SymmetryEmpties %d，QuiesceFlips %d，QuiesceDelta %d\n",
+		c.Len(), s.Evaluator, s.SymmetryEmpties, s.QuiesceFlips, s.QuiesceDelta)
+	h := c.DepthHistogram()
+	depths := make([]int, 0, len(h))
+	for d := range h {
+		depths = append(depths, d)
+	}
+	sort.Ints(depths)
+	for _, d := range depths {
+		fmt.Printf("  深度 %2d: %d\n", d, h[d])
+	}
+}
+
+func save(c *game.TTCache, path string) {
+	if err := c.Save(path); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("缓存 %d 个条目，已写到 %s", c.Len(), path)
+}
+
+func loadReplays(path string) ([]match, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var matches []match
+	if err := json.Unmarshal(data, &matches); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return matches, nil
+}
@@ -0,0 +1,230 @@
+// cmd/tune：Texel 调参。读 selfplay 写出的 CSV，调 evaluateStatic 的权重并写出权重文件。
+//
+//	go run ./cmd/tune -out tuned.json dataset.csv [more.csv ...]
+//
+// 之后 hexxagon / selfplay 用 -weights tuned.json 即可。
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"flag"
+	"io"
+	"log"
+	"math/rand"
+	"os"
+	"runtime"
+	"strconv"
+	"sync"
+
+	"hexxagon_go/internal/game"
+)
+
+// CSV 每行：0..242 张量（行棋方视角）| 243 走法索引 | 244 z（A 方视角的胜负）| 245 对局号
+const (
+	colMove = game.TensorLen
+	colZ    = game.TensorLen + 1
+	colGame = game.TensorLen + 2
+	numCols = game.TensorLen + 3
+)
+
+func main() {
+	initPath := flag.String("init", "", "起始权重文件（空则从默认权重出发）")
+	outPath := flag.String("out", "tuned_weights.json", "调好的权重写到这里")
+	skip := flag.Int("skip", 8, "每局跳过开头这么多个局面（selfplay 开局有随机步）")
+	maxPos := flag.Int("max", 0, "最多用多少个局面（随机抽样，0=全部）")
+	valFrac := flag.Float64("val", 0.1, "按对局划出这么多做验证集")
+	rounds := flag.Int("rounds", 100, "局部搜索最多几轮")
+	fixedK := flag.Float64("k", 0, "sigmoid 的 K（0=按起始权重拟合）")
+	seed := flag.Int64("seed", 1, "抽样与划分验证集的随机种子")
+	dedup := flag.Bool("dedup", false, "互为对称的重复局面只留第一次出现的（按规范局面 + 行棋方）")
+	flag.Parse()
+	if flag.NArg() == 0 {
+		log.Fatal("用法: tune [参数] dataset.csv [...]")
+	}
+
+	w := game.DefaultEvalWeights()
+	if *initPath != "" {
+		var err error
+		if w, err = game.LoadEvalWeights(*initPath); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	var games [][]position
+	for _, path := range flag.Args() {
+		g, err := readGames(path, *skip)
+		if err != nil {
+			log.Fatal(err)
+		}
+		games = append(games, g...)
+	}
+	if *dedup {
+		log.Printf("去重删掉 %d 个重复局面", dedupPositions(games))
+	}
+
+	// 按对局划分训练/验证，同一局的局面高度相关，不能拆开
+	r := rand.New(rand.NewSource(*seed))
+	r.Shuffle(len(games), func(i, j int) { games[i], games[j] = games[j], games[i] })
+	nVal := int(float64(len(games)) * *valFrac)
+	var trainPos, valPos []position
+	for i, g := range games {
+		if i < nVal {
+			valPos = append(valPos, g...)
+		} else {
+			trainPos = append(trainPos, g...)
+		}
+	}
+	if *maxPos > 0 && len(trainPos) > *maxPos {
+		r.Shuffle(len(trainPos), func(i, j int) { trainPos[i], trainPos[j] = trainPos[j], trainPos[i] })
+		trainPos = trainPos[:*maxPos]
+	}
+	if len(trainPos) == 0 {
+		log.Fatal("没有可用的局面")
+	}
+	log.Printf("%d 局，训练 %d 个局面，验证 %d 个", len(games), len(trainPos), len(valPos))
+
+	train, val := extract(trainPos), extract(valPos)
+	k := *fixedK
+	if k <= 0 {
+		k = game.FitTexelK(train, w)
+	}
+	log.Printf("K=%.5f，起始误差 训练 %.6f 验证 %.6f", k, game.TexelLoss(train, w, k), game.TexelLoss(val, w, k))
+
+	tuned, loss := game.TuneEvalWeights(train, w, k, game.TuneOptions{
+		MaxRounds: *rounds,
+		Progress: func(round int, loss float64, w game.EvalWeights) {
+			log.Printf("第 %d 轮 误差 %.6f %+v", round, loss, w)
+		},
+	})
+	log.Printf("调参结束 训练 %.6f 验证 %.6f", loss, game.TexelLoss(val, tuned, k))
+	if err := tuned.Save(*outPath); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("权重已写到 %s", *outPath)
+}
+
+// position 一个还原好的局面与结果（side 视角）
+type position struct {
+	board  *game.Board
+	side   game.CellState
+	result float64
+}
+
+// readGames 按对局号把连续的行归成一局。selfplay 从 A 开始轮流走，行号的奇偶就是行棋方；
+// 上一行的走法就是走到本局面的那一步，补回 LastMove（弱跳越那一项要用）
+func readGames(path string, skip int) ([][]position, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	rd := csv.NewReader(bufio.NewReaderSize(f, 1<<20))
+	rd.FieldsPerRecord = -1
+	rd.ReuseRecord = true
+
+	var (
+		games   [][]position
+		cur     []position
+		curID   string
+		ply     int
+		prevMv  = -1
+		tensor  = make([]float32, game.TensorLen)
+		badRows int
+	)
+	flush := func() {
+		if len(cur) > 0 {
+			games = append(games, cur)
+		}
+		cur = nil
+	}
+	for {
+		rec, err := rd.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(rec) != numCols {
+			badRows++
+			continue
+		}
+		if rec[colGame] != curID {
+			flush()
+			curID, ply, prevMv = rec[colGame], 0, -1
+		}
+		z, err1 := strconv.Atoi(rec[colZ])
+		mv, err2 := strconv.Atoi(rec[colMove])
+		if err1 != nil || err2 != nil {
+			badRows++
+			continue
+		}
+		side := game.PlayerA
+		if ply%2 == 1 {
+			side = game.PlayerB
+		}
+		if ply >= skip {
+			for i := range tensor {
+				tensor[i] = 0
+				if rec[i] != "0" {
+					tensor[i] = 1
+				}
+			}
+			b := game.DecodeBoardTensor(tensor, side)
+			if prevMv >= 0 {
+				b.LastMove = game.PolicyIndexToMove(prevMv)
+			}
+			res := 0.5 + 0.5*float64(z) // A 方视角 → 0/0.5/1
+			if side == game.PlayerB {
+				res = 1 - res
+			}
+			cur = append(cur, position{board: b, side: side, result: res})
+		}
+		ply++
+		prevMv = mv
+	}
+	flush()
+	if badRows > 0 {
+		log.Printf("%s: 跳过 %d 行格式不对的数据", path, badRows)
+	}
+	return games, nil
+}
+
+// dedupPositions 按 game.CanonicalKey 原地删掉重复局面，返回删掉的个数
+func dedupPositions(games [][]position) int {
+	seen := make(map[uint64]struct{})
+	removed := 0
+	for i, g := range games {
+		kept := g[:0]
+		for _, p := range g {
+			key := game.CanonicalKey(p.board, p.side)
+			if _, dup := seen[key]; dup {
+				removed++
+				continue
+			}
+			seen[key] = struct{}{}
+			kept = append(kept, p)
+		}
+		games[i] = kept
+	}
+	return removed
+}
+
+// extract 并行抽特征
+func extract(ps []position) []game.TuneSample {
+	out := make([]game.TuneSample, len(ps))
+	workers := runtime.NumCPU()
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := w; i < len(ps); i += workers {
+				out[i] = game.TuneSample{F: game.StaticFeatures(ps[i].board, ps[i].side), Result: ps[i].result}
+			}
+		}(w)
+	}
+	wg.Wait()
+	return out
+}
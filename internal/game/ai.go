@@ -3,11 +3,13 @@ package game
 
 import (
 	//"fmt"
+	"context"
 	"math"
 	"math/rand"
 	"runtime"
 	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -17,8 +19,6 @@ func init() {
 
 }
 
-// const useLearned = true
-const useLearned = false
 const jumpMovePenalty = 25
 
 // ------------------------------------------------------------
@@ -26,72 +26,79 @@ const jumpMovePenalty = 25
 // ------------------------------------------------------------
 func cloneBoardPool(b *Board) *Board {
 	nb := acquireBoard(b.radius)
-	// —— 确保 cells map 已初始化且已清空 ——
-	if nb.cells == nil {
-		nb.cells = make(map[HexCoord]CellState, len(b.cells))
-	} else {
-		for k := range nb.cells {
-			delete(nb.cells, k)
-		}
-	}
-	// 复制 cells 数据
-	for c, s := range b.cells {
-		nb.cells[c] = s
-	}
-	// 同步 hash
-	nb.hash = b.hash
+	// 位棋盘直接整体拷贝，hash 一并同步
+	*nb = *b
 	return nb
 }
 
 func cloneBoard(b *Board) *Board {
-	// 分配全新的 map，绝不复用
-	nb := &Board{
-		radius: b.radius,
-		cells:  make(map[HexCoord]CellState, len(b.cells)),
-		hash:   b.hash,
+	// 分配全新的棋盘，绝不复用
+	nb := *b
+	nb.LastMove = Move{}
+	return &nb
+}
+
+// FindBestMoveAtDepth 固定深度搜索 player 的最佳着；根节点用 Lazy SMP 多线程搜（见 searchRootSMP）。
+// 配了开局库且命中时直接用库里的着；
+// 空格数进入残局阈值时先试 SolveEndgame（至多 endgameTimeCap），解不出再按深度搜。
+func (e *Engine) FindBestMoveAtDepth(b *Board, player CellState, depth int) (Move, bool) {
+	if mv, ok := e.probeBook(b, player); ok {
+		return mv, true
 	}
-	for c, s := range b.cells {
-		nb.cells[c] = s
+	if e.inEndgame(b) {
+		if res, ok := e.searchEndgame(context.Background(), b, player, SearchLimits{}); ok {
+			return res.Move, res.OK
+		}
 	}
-	return nb
+	e.stop.Store(false)
+	e.nodes.Store(0)
+	e.tt.newSearch()
+	res := e.searchDepth(b, player, depth, nil)
+	return res.move, res.ok
+}
+
+// rootResult 是一层根搜索的结果
+type rootResult struct {
+	move   Move
+	score  int
+	pv     []Move       // 以 move 开头的主变例
+	scores map[Move]int // 本层每个根走法的分数，供下一层排序
+	ok     bool
 }
 
-func FindBestMoveAtDepth(b *Board, player CellState, depth int) (Move, bool) {
-	ttProbeCount = 0
-	ttHitCount = 0
+// searchDepth 是 FindBestMoveAtDepth 的实现，额外返回分数与主变例。
+// prev 为上一层各根走法的分数，非空时按它排序根走法。
+// 搜索中途被 stop 打断时结果无意义，由调用方丢弃。
+func (e *Engine) searchDepth(b *Board, player CellState, depth int, prev map[Move]int) rootResult {
+	e.tt.resetStats()
 
 	if mv, ok := findImmediateWinOrSafeClone(b, player); ok {
-		return mv, true
+		nb := cloneBoard(b)
+		mMakeMoveWithUndo(nb, mv, player)
+		return rootResult{move: mv, score: e.eval(nb, player), pv: []Move{mv}, ok: true}
 	}
 	moves := GenerateMoves(b, player)
 	if len(moves) == 0 {
-		return Move{}, false
+		return rootResult{}
 	}
 
 	// —— 新增：根节点就过滤 0 感染跳越 ——
 	moves = filterZeroInfectJumpsOrFallback(b, player, moves)
 	if len(moves) == 0 {
-		return Move{}, false
+		return rootResult{}
 	}
 
 	//const depth = 4
 	const inf = 1 << 30
 
 	// 1) 计算空位比例 r
-	coords := b.AllCoords()
-	empties := 0
-	for _, c := range coords {
-		if b.Get(c) == Empty {
-			empties++
-		}
-	}
-	r := float64(empties) / float64(len(coords))
+	r := float64(b.CountPieces(Empty)) / float64(len(b.AllCoords()))
 	// --- 开局极早期强制只克隆 ---
 	const earlyCloneThresh = 0.76 // 当空位 ≥90%，视为开局极早期
 	const earlyCloneThresh2 = 0.76
 	//fmt.Println(r)
-	if r >= earlyCloneThresh2 {
-		// 开局早期：只保留“外圈克隆”走法
+	if e.opts.Book == nil && r >= earlyCloneThresh2 {
+		// 开局早期（没配开局库时）：只保留“外圈克隆”走法
 		var edgeClones []Move
 		for _, m := range moves {
 			if m.IsClone() && isOuter(m.To, b.radius) {
@@ -115,87 +122,25 @@ func FindBestMoveAtDepth(b *Board, player CellState, depth int) (Move, bool) {
 	if len(pruned) > 0 {
 		moves = pruned
 	}
-	// ---------- 1) 走法粗评分（真实 evaluate） ----------
-	type scored struct {
-		mv    Move
-		score int
-	}
-	order := make([]scored, len(moves))
-
-	for i, m := range moves {
-		// 记录哈希
-		origHash := b.hash
-		// 执行落子
-		undo := mMakeMoveWithUndo(b, m, player)
-		// 静态评估
-		var score int
-		if useLearned {
-			score = EvaluateNN(b, player)
-		} else {
-			score = evaluateStatic(b, player)
-		}
-
-		// 回溯
-		b.UnmakeMove(undo)
-		b.hash = origHash
-
-		order[i] = scored{m, score}
-	}
-
-	sort.Slice(order, func(i, j int) bool {
-		if order[i].score != order[j].score {
-			return order[i].score > order[j].score // 感染多的先
-		}
-		// 同感染数：Clone 先、Jump 后
-		if order[i].mv.IsClone() != order[j].mv.IsClone() {
-			return order[i].mv.IsClone() // Clone=true 放前面
-		}
-		return false
-	})
-	// ---------- 2) 并行根节点 α–β 搜索 ----------
-	type result struct {
-		mv    Move
-		score int
-	}
-	resCh := make(chan result, len(order))
-	var wg sync.WaitGroup
-
-	alphaRoot, betaRoot := -inf, inf
-
-	for _, item := range order {
-		wg.Add(1)
-		go func(it scored) {
-			defer wg.Done()
-			// 【改动】从池里拿，不要用 cloneBoard
-			nb := cloneBoardPool(b)
-			//_, _ = it.mv.MakeMove(nb, player)
-			// 清理一下，避免池里残留
-			nb.LastMove = Move{}
-			// 用统一入口，保证 LastMove 被写入
-			_ = mMakeMoveWithUndo(nb, it.mv, player) // 丢掉 undo 没关系，这块本来就不回滚
-			score := alphaBeta(
-				nb, nb.hash,
-				Opponent(player), player,
-				depth-1, alphaRoot, betaRoot,
-			)
-			// 用完再放回池里
-			releaseBoard(nb)
-
-			resCh <- result{it.mv, score}
-		}(item)
+	// ---------- 1)+2) 根走法排序 + Lazy SMP ----------
+	results := e.searchRootSMP(b, player, depth, moves, prev)
+	if len(results) == 0 {
+		return rootResult{} // 被打断
 	}
-	wg.Wait()
-	close(resCh)
-	probes, hits, rate := GetTTStats()
+	probes, hits, rate := e.tt.stats()
 	_, _, _ = probes, hits, rate
 	//fmt.Printf("TT probes: %d, hits: %d, hit rate: %.2f%%\n", probes, hits, rate)
 	// ---------- 3) 汇总最佳 + ε–贪心同分支 ----------
 	bestScore := -inf
 	secondScore := -inf
 	var bestMoves []Move
+	scores := make(map[Move]int, len(results))
+	lines := make(map[Move][]Move, len(results))
 
-	for r := range resCh {
+	for _, r := range results {
 		score := r.score
+		scores[r.mv] = score
+		lines[r.mv] = r.pv
 
 		// 如果当前分数高于 bestScore，更新 bestScore 和 secondScore
 		if score > bestScore {
@@ -231,237 +176,196 @@ func FindBestMoveAtDepth(b *Board, player CellState, depth int) (Move, bool) {
 		choice = bestMoves[rand.Intn(len(bestMoves))]
 	}
 
-	return choice, true
-
+	return rootResult{
+		move:   choice,
+		score:  bestScore,
+		pv:     lines[choice],
+		scores: scores,
+		ok:     true,
+	}
 }
 
-// ------------------------------------------------------------
-// α-β + 置换表
-// ------------------------------------------------------------
-func mMakeMoveWithUndo(b *Board, mv Move, player CellState) undoInfo {
-	// 确保评估能看到“刚才这步”
-	b.LastMove = mv
-
-	infected, u := mv.MakeMove(b, player)
-	_ = infected
-	return u
+// rootLine 是单个根走法的搜索结果
+type rootLine struct {
+	mv    Move
+	score int
+	pv    []Move // 以 mv 开头的主变例
 }
 
-func alphaBeta(
-	b *Board,
-	hash uint64,
-	current, original CellState,
-	depth, alpha, beta int,
-) int {
-	// ———— 新增 —— 在函数开头，先计算空位比例 r，用于判断是否处于“开局前期” ————
-	coords := b.AllCoords()
-	empties := 0
-	for _, c := range coords {
-		if b.Get(c) == Empty {
-			empties++
-		}
-	}
-	//r := float64(empties) / float64(len(coords))
-	// ————————————————————————————————————————————————————————————————
-
-	// 1) 生成所有走法
-	moves := GenerateMoves(b, current)
-
-	// 2) 叶节点或无走子：直接评估，并写入置换表
-	if depth == 0 || len(moves) == 0 {
-		var val int
-		if useLearned {
-			val = EvaluateNN(b, original)
-		} else {
-			val = evaluateStatic(b, original)
-		}
-		storeTT(hash, depth, val, ttExact)
-		return val
-	}
-
-	// 3) 置换表探测
-	if hit, val, flag := probeTT(hash, depth); hit {
-		switch flag {
-		case ttExact:
-			return val
-		case ttLower:
-			if val > alpha {
-				alpha = val
-			}
-		case ttUpper:
-			if val < beta {
-				beta = val
-			}
-		}
-		if alpha >= beta {
-			return val
-		}
+// orderRootMoves 根走法排序：上一层搜过的走法用它的搜索分，没有的按静态评估；同分克隆在前
+func (e *Engine) orderRootMoves(b *Board, player CellState, moves []Move, prev map[Move]int) []Move {
+	// ---------- 走法粗评分（真实 evaluate） ----------
+	type scored struct {
+		mv    Move
+		score int
 	}
-	alphaOrig := alpha
-	betaOrig := beta
+	order := make([]scored, len(moves))
 
-	// 4) PV-Move 排序：如果置换表里有记录的最佳走法索引，把它交换到 moves[0]
-	if ok, idx := probeBestIdx(hash); ok {
-		i := int(idx)
-		if i < len(moves) {
-			moves[0], moves[i] = moves[i], moves[0]
+	for i, m := range moves {
+		// 上一层搜过的走法直接用它的搜索分
+		if s, ok := prev[m]; ok {
+			order[i] = scored{m, s}
+			continue
 		}
-	}
+		// 执行落子
+		undo := mMakeMoveWithUndo(b, m, player)
+		// 静态评估
+		score := e.eval(b, player)
 
-	var bestScore int
-	var bestIdx uint8
+		// 回溯
+		b.UnmakeMove(undo)
 
-	// 5) 根据是“极大化节点”还是“极小化节点”分别处理
-	if current == original {
-		// === MAX 节点 ===
-		bestScore = math.MinInt32
+		order[i] = scored{m, score}
+	}
 
-		// ———— 新增：过滤开局阶段 0 感染跳跃 ————
-		var filtered []Move
-		for _, mv := range moves {
-			//if r >= openingPhaseThresh && mv.IsJump() && previewInfectedCount(b, mv, current) == 0 {
-			if mv.IsJump() && previewInfectedCount(b, mv, current) == 0 {
-				// 跳跃但0感染，丢弃
-				continue
-			}
-			filtered = append(filtered, mv)
+	sort.Slice(order, func(i, j int) bool {
+		if order[i].score != order[j].score {
+			return order[i].score > order[j].score // 感染多的先
 		}
-		moves = filtered
-		// 如果全部走法都被过滤，回退到原始走法列表，避免空搜索
-		//if len(moves) == 0 {
-		//	moves = GenerateMoves(b, current)
-		//}
-		// ——————————————————————————————
-
-		// PV-Move 排序：置换表里记录的最佳索引先尝试
-		if ok, idx := probeBestIdx(hash); ok {
-			i := int(idx)
-			if i < len(moves) {
-				moves[0], moves[i] = moves[i], moves[0]
-			}
+		// 同感染数：Clone 先、Jump 后
+		if order[i].mv.IsClone() != order[j].mv.IsClone() {
+			return order[i].mv.IsClone() // Clone=true 放前面
 		}
+		return false
+	})
+	out := make([]Move, len(order))
+	for i, o := range order {
+		out[i] = o.mv
+	}
+	return out
+}
 
-		// 遍历剩余走法
-		for i, mv := range moves {
-			// 计算增量 Zobrist hash
-			origHash := b.hash
-			childHash := origHash ^ zobristSide[sideIdx(current)]
+// searchRootMoves 对每个根走法做全窗口 α-β，分数都是精确值（Analyze 的多 PV 用）。
+// prev 为上一层各根走法的分数，用来排序；没有的走法按静态评估排序。
+//
+// 这里不走 Lazy SMP（searchRootSMP）：那边主线程对根做 PVS，除最佳着外只拿到零窗口的界，
+// 多 PV 要的是每个根走法的精确分，只能逐个全窗口搜。并行方式是 threads() 个 goroutine
+// 分摊根走法，同样共用置换表。
+func (e *Engine) searchRootMoves(b *Board, player CellState, depth int, moves []Move, prev map[Move]int) []rootLine {
+	order := e.orderRootMoves(b, player, moves, prev)
+	todo := make(chan Move, len(order))
+	for _, mv := range order {
+		todo <- mv
+	}
+	close(todo)
+	resCh := make(chan rootLine, len(order))
+	var wg sync.WaitGroup
 
-			// from → Empty（若跳跃则额外清除原位）
-			childHash ^= zobristKey(mv.From, current)
-			if mv.IsJump() {
-				childHash ^= zobristKey(mv.From, Empty)
-			}
-			// to → current
-			childHash ^= zobristKey(mv.To, Empty)
-			childHash ^= zobristKey(mv.To, current)
-			// 感染翻转
-			for _, n := range b.Neighbors(mv.To) {
-				if b.Get(n) == Opponent(current) {
-					childHash ^= zobristKey(n, Opponent(current))
-					childHash ^= zobristKey(n, current)
-				}
+	for range min(e.threads(), len(order)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for mv := range todo {
+				// 【改动】从池里拿，不要用 cloneBoard
+				nb := cloneBoardPool(b)
+				// 清理一下，避免池里残留
+				nb.LastMove = Move{}
+				// 用统一入口，保证 LastMove 被写入
+				_ = mMakeMoveWithUndo(nb, mv, player) // 丢掉 undo 没关系，这块本来就不回滚
+				var line []Move
+				// 每个根走法独立的杀手/历史表；根节点全窗口，分数是精确值
+				t := e.newThread(nb)
+				score := -e.negamax(t, nb, Opponent(player), depth-1, 1, -searchInf, searchInf, &line)
+				// 用完再放回池里
+				releaseBoard(nb)
+
+				resCh <- rootLine{mv, score, append([]Move{mv}, line...)}
 			}
-			// 切换行棋方
-			next := Opponent(current)
-			childHash ^= zobristSide[sideIdx(next)]
-			// 更新棋盘 hash
-			b.hash = childHash
-
-			// 真正落子
-			undo := mMakeMoveWithUndo(b, mv, current)
+		}()
+	}
+	wg.Wait()
+	close(resCh)
 
-			// 递归搜索
-			score := alphaBeta(b, childHash, next, original, depth-1, alpha, beta)
+	results := make([]rootLine, 0, len(order))
+	for r := range resCh {
+		results = append(results, r)
+	}
+	return results
+}
 
-			// 回溯
-			b.UnmakeMove(undo)
-			b.hash = origHash
+// threads Lazy SMP 的线程数（含主线程）
+func (e *Engine) threads() int {
+	if e.opts.Threads > 0 {
+		return e.opts.Threads
+	}
+	return runtime.GOMAXPROCS(0)
+}
 
-			// （可选）对所有跳跃加上固定惩罚，比如 jumpMovePenalty
-			if mv.IsJump() && !useLearned {
-				score -= jumpMovePenalty
-			}
+// searchRootSMP 是 Lazy SMP 驱动：主线程按排好的顺序对根节点做 PVS；
+// 另外 threads-1 个辅助线程在各自的棋盘副本上搜同一个根，奇数号多搜一层、根走法轮换起点，
+// 搜完一层接着加深，彼此只通过共享置换表交换结果。主线程搜完就叫停辅助线程，结果只取主线程的。
+// 被取消时返回 nil。
+func (e *Engine) searchRootSMP(b *Board, player CellState, depth int, moves []Move, prev map[Move]int) []rootLine {
+	order := e.orderRootMoves(b, player, moves, prev)
 
-			// 更新 bestScore / α / β-剪枝
-			if score > bestScore {
-				bestScore = score
-				bestIdx = uint8(i)
-			}
-			if score > alpha {
-				alpha = score
-				if alpha >= beta {
-					break
-				}
+	var (
+		halt atomic.Bool
+		wg   sync.WaitGroup
+	)
+	for i := 1; i < e.threads(); i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			nb := cloneBoardPool(b)
+			defer releaseBoard(nb)
+			t := e.newThread(nb)
+			t.halt = &halt
+			k := i % len(order)
+			rot := append(append([]Move(nil), order[k:]...), order[:k]...)
+			for d := depth + i%2; d < maxPly && !e.aborted(t); d++ {
+				e.searchRoot(t, nb, player, d, rot)
 			}
-		}
-	} else {
-		// === MIN 节点 ===
-		bestScore = math.MaxInt32
-		for i, mv := range moves {
-			// 如果你只想给 MAX 侧惩罚，那么这里可以不做任何改动；否则下面也可以照着 MAX 的做法—给 MIN 侧的“非感染跳跃”一个很高的分数，使 MIN 不愿意选它。
-			// 通常我们只对 MAX 侧进行“非感染跳跃惩罚”，所以这里不加惩罚判断——保持原样即可。
-
-			childHash := hash
-			// ★ 0) 去掉当前行棋方
-			childHash ^= zobristSide[sideIdx(current)]
-
-			// ①–⑤ 增量 XOR 格子状态
-			childHash ^= zobristKey(mv.From, current)
-
-			//if mv.IsJump() {
-			//	childHash = childHash ^ zobristKey(mv.From, current) ^ zobristKey(mv.From, Empty)
-			//}
-			next := Opponent(current)
-			childHash ^= zobristSide[sideIdx(next)] // ★ 新增
-
-			childHash = childHash ^ zobristKey(mv.To, Empty) ^ zobristKey(mv.To, current)
-
-			// 执行落子并记录 undo
-			undo := mMakeMoveWithUndo(b, mv, current)
-
-			// 递归
-			score := alphaBeta(b, childHash, Opponent(current), original, depth-1, alpha, beta)
-
-			// 回溯
-			b.UnmakeMove(undo)
-
-			if mv.IsJump() {
-				if !useLearned {
-					// 由于 MIN 节点是在找最小 score，所以想让它不喜欢跳，就给它加一个很大的正分：
-					score += jumpMovePenalty
-				}
+		}(i)
+	}
 
-			}
+	nb := cloneBoardPool(b)
+	results := e.searchRoot(e.newThread(nb), nb, player, depth, order)
+	releaseBoard(nb)
+	halt.Store(true)
+	wg.Wait()
+	return results
+}
 
-			// 更新 best, β, 剪枝
-			if score < bestScore {
-				bestScore = score
-				bestIdx = uint8(i)
-			}
-			if score < beta {
-				beta = score
-			}
-			if beta <= alpha {
-				// 触发 α-剪枝
-				break
+// searchRoot 根节点 PVS。第一个走法全窗口；其余先用 (α-1, α) 的零窗口问“能不能追平”，
+// 能追平或超过的再重搜拿精确分，所以和最佳着同分的走法都有精确分，searchDepth 才能在同分里挑；
+// 其余走法的分数只是上界，只拿来给下一层排序。被取消时返回 nil。
+func (e *Engine) searchRoot(t *searchThread, b *Board, player CellState, depth int, order []Move) []rootLine {
+	next := Opponent(player)
+	results := make([]rootLine, 0, len(order))
+	alpha := -searchInf
+	for i, mv := range order {
+		undo := t.makeMove(b, mv, player)
+		var line []Move
+		var score int
+		if i == 0 {
+			score = -e.negamax(t, b, next, depth-1, 1, -searchInf, searchInf, &line)
+		} else {
+			score = -e.negamax(t, b, next, depth-1, 1, -alpha, -alpha+1, &line)
+			if score >= alpha {
+				line = line[:0]
+				score = -e.negamax(t, b, next, depth-1, 1, -searchInf, -alpha+1, &line)
 			}
 		}
+		t.unmakeMove(b, undo)
+		if e.aborted(t) {
+			return nil
+		}
+		results = append(results, rootLine{mv, score, append([]Move{mv}, line...)})
+		alpha = max(alpha, score)
 	}
+	return results
+}
 
-	// 6) 写回置换表
-	var flag ttFlag
-	switch {
-	case bestScore <= alphaOrig:
-		flag = ttUpper
-	case bestScore >= betaOrig:
-		flag = ttLower
-	default:
-		flag = ttExact
-	}
-	storeTT(hash, depth, bestScore, flag)
-	storeBestIdx(hash, bestIdx)
-	return bestScore
+// ------------------------------------------------------------
+// 落子辅助；搜索核心见 negamax.go
+// ------------------------------------------------------------
+func mMakeMoveWithUndo(b *Board, mv Move, player CellState) undoInfo {
+	// 确保评估能看到“刚才这步”
+	b.LastMove = mv
+
+	infected, u := mv.MakeMove(b, player)
+	_ = infected
+	return u
 }
 
 // ------------------------------------------------------------
@@ -480,12 +384,7 @@ func min(a, b int) int {
 
 func chooseEndgameDepth(b *Board, base int) int {
 	// 统计空格
-	empties := 0
-	for _, c := range b.AllCoords() {
-		if b.Get(c) == Empty {
-			empties++
-		}
-	}
+	empties := b.CountPieces(Empty)
 	switch {
 	case empties <= 6:
 		// 残局很小，基本可以搜到底（每回合至少占/改变1格，给点冗余）
@@ -514,12 +413,7 @@ func findImmediateWinOrSafeClone(b *Board, p CellState) (Move, bool) {
 		_, _ = mv.MakeMove(nb, p)
 
 		// 1) 立即终局 = 对手无棋 or 无空格：直接选
-		empties := 0
-		for _, c := range nb.AllCoords() {
-			if nb.Get(c) == Empty {
-				empties++
-			}
-		}
+		empties := nb.CountPieces(Empty)
 		if len(GenerateMoves(nb, op)) == 0 || empties == 0 {
 			return mv, true
 		}
@@ -545,52 +439,26 @@ func findImmediateWinOrSafeClone(b *Board, p CellState) (Move, bool) {
 	return best, found
 }
 
-func DeepSearch(b *Board, hash uint64, side CellState, depth int) int {
-
-	return alphaBeta(b, hash, side, side, depth, -32000, 32000)
+// DeepSearch 从 side 先走做一次固定深度搜索，返回 side 视角的分数。
+// 节点键由棋盘哈希和行棋方现算，hash 参数只为兼容旧调用方保留。
+func (e *Engine) DeepSearch(b *Board, _ uint64, side CellState, depth int) int {
+	return e.negamax(e.newThread(b), b, side, depth, 0, -searchInf, searchInf, new([]Move))
 }
 
-func IterativeDeepening(
+// IterativeDeepening 不限时地从 1 层加深到 maxDepth
+func (e *Engine) IterativeDeepening(
 	root *Board,
 	player CellState,
 	maxDepth int,
 ) (best Move, bestScore int, ok bool) {
-
-	// 用于存上一层 PV 走法的哈希 → bestIdx
-	pvMove := make(map[uint64]uint8)
-
-	for depth := 1; depth <= maxDepth; depth++ {
-		// 把上一层保存的 PV-Move 写进 TT，供排序
-		for h, idx := range pvMove {
-			storeBestIdx(h, idx)
-		}
-		// 调用已有的并行根节点搜索
-		depth2 := chooseEndgameDepth(root, depth)
-		mv, hit := FindBestMoveAtDepth(root, player, depth2)
-		if !hit {
-			break // 无合法走法
-		}
-		// 记录本层 PV-Move：根节点 hash → idx=0
-		pvMove[root.hash] = 0
-		best, bestScore, ok = mv, 0, true // 根节点时 score 在内部已比较
-	}
-	return
+	res := e.Search(context.Background(), root, player, SearchLimits{MaxDepth: maxDepth})
+	return res.Move, res.Score, res.OK
 }
 
-func AlphaBeta(b *Board, player CellState, depth int) int {
-	// 1) 把“行棋方”也异或进哈希，确保置换表区分 Max/Min
-	initialHash := b.hash ^ zobristSide[sideIdx(player)]
-
-	// 2) 调用内层实现：先轮到对手走，再到 player
-	return alphaBeta(
-		b,
-		initialHash,
-		Opponent(player), // current = 对手
-		player,           // original = 我方
-		depth,
-		math.MinInt, // 初始 α
-		math.MaxInt, // 初始 β
-	)
+// AlphaBeta 评估“player 刚走完、轮到对手”的局面，返回 player 视角的分数
+func (e *Engine) AlphaBeta(b *Board, player CellState, depth int) int {
+	// negamax 返回行棋方（对手）视角，取反回到 player
+	return -e.negamax(e.newThread(b), b, Opponent(player), depth, 0, -searchInf, searchInf, new([]Move))
 }
 
 // alphaBetaNoTT 在 b 上执行一次不带置换表的 α–β 搜索。
@@ -602,9 +470,8 @@ func AlphaBeta(b *Board, player CellState, depth int) int {
 // 对外包装器 —— 只要 3 个参数即可调用
 // ------------------------------------------------------------
 func AlphaBetaNoTT(b *Board, player CellState, depth int) int {
-	// 根节点先把“行棋方随机键” XOR 进去，保证 hash 正确
-	initialHash := hashBoard(b) ^ zobristSide[sideIdx(player)]
-	b.hash = initialHash
+	// 不用置换表，这里只把棋盘哈希重新对齐一次
+	b.hash = hashBoard(b)
 
 	// 递归从对手开始（current），original = player
 	return alphaBetaNoTT(
@@ -0,0 +1,77 @@
+// internal/game/analysis.go
+package game
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// AnalyzeOptions 控制多 PV 分析
+type AnalyzeOptions struct {
+	MultiPV int    // 返回前几条线；<=0 返回全部
+	Moves   []Move // 只分析这些根走法；为空则分析全部合法走法
+}
+
+// AnalysisLine 是一个根走法的分析结果
+type AnalysisLine struct {
+	Move  Move
+	Score int    // player 视角
+	PV    []Move // 以 Move 开头的主变例
+}
+
+// Analysis 是多 PV 分析结果，来自最后一层完整搜完的深度
+type Analysis struct {
+	Lines []AnalysisLine // 按分数从高到低
+	Depth int
+	Nodes uint64
+	Time  time.Duration
+}
+
+// Analyze 对根局面做多 PV 分析：每个候选根走法都用全窗口搜到同一深度，
+// 分数可以互相比较。与 Search 不同，这里不做开局/策略剪枝，也不随机挑同分着，
+// 适合提示叠层、复盘注释和训练数据打标。计时与取消规则同 Search。
+func (e *Engine) Analyze(ctx context.Context, root *Board, player CellState, limits SearchLimits, opts AnalyzeOptions) Analysis {
+	moves := opts.Moves
+	if len(moves) == 0 {
+		moves = GenerateMoves(root, player)
+	}
+	var (
+		res  Analysis
+		prev map[Move]int
+	)
+	if len(moves) == 0 {
+		return res
+	}
+
+	res.Time = e.deepen(ctx, root, limits, func(depth, depth2 int) bool {
+		e.tt.resetStats()
+		results := e.searchRootMoves(root, player, depth2, moves, prev)
+		if e.stop.Load() {
+			return false
+		}
+		sort.SliceStable(results, func(i, j int) bool { return results[i].score > results[j].score })
+
+		lines := make([]AnalysisLine, 0, len(results))
+		prev = make(map[Move]int, len(results))
+		for _, r := range results {
+			prev[r.mv] = r.score
+			lines = append(lines, AnalysisLine{
+				Move:  r.mv,
+				Score: r.score,
+				PV:    e.extendPV(root, player, r.pv, depth2),
+			})
+		}
+		// 只给最好的那条播种，免得互相覆盖
+		e.seedPV(root, player, lines[0].PV)
+
+		if opts.MultiPV > 0 && len(lines) > opts.MultiPV {
+			lines = lines[:opts.MultiPV]
+		}
+		res.Lines = lines
+		res.Depth = depth
+		return true
+	})
+	res.Nodes = e.nodes.Load()
+	return res
+}
@@ -1,6 +1,9 @@
 import argparse, torch, torch.nn as nn, torch.nn.functional as F
 import onnx, onnxruntime as ort
 import numpy as np
+import struct
+
+POLICY_LEN = 81 * 18  # 落点 × 方向(6 克隆 + 12 跳跃)，与 Go 侧 game.PolicyLen 一致
 
 # ------- 模型结构（和训练一致） -------
 class ResidualBlock(nn.Module):
@@ -19,13 +22,13 @@ class HexResNet(nn.Module):
                                   nn.BatchNorm2d(ch), nn.ReLU(inplace=True))
         self.body = nn.Sequential(*[ResidualBlock(ch) for _ in range(blocks)])
         self.head_p = nn.Sequential(nn.Conv2d(ch, 32, 1), nn.ReLU(inplace=True),
-                                    nn.Flatten(), nn.Linear(32*9*9, 81))
+                                    nn.Flatten(), nn.Linear(32*9*9, POLICY_LEN))
         self.head_v = nn.Sequential(nn.Conv2d(ch, 32, 1), nn.ReLU(inplace=True),
                                     nn.AdaptiveAvgPool2d(1), nn.Flatten(),
                                     nn.Linear(32, 1), nn.Tanh())
     def forward(self, x, mask=None):
         x = self.stem(x); x = self.body(x)
-        p = self.head_p(x)   # (B,81)
+        p = self.head_p(x)   # (B,POLICY_LEN)
         v = self.head_v(x)   # (B,1)
         return p, v
 
@@ -55,6 +58,27 @@ def infer_arch(sd):
         blocks = 1
     return ch, blocks
 
+def export_bin(net, path):
+    # 纯 Go 前向用的权重（格式见 internal/game/cnn.go）：小端 float32，BN 原样导出、Go 侧加载时折叠
+    sd = {k: v.detach().cpu().numpy().astype("<f4") for k, v in net.state_dict().items()}
+    ch, blocks = net.stem[0].out_channels, len(net.body)
+    head_ch = net.head_p[0].out_channels
+    eps = net.stem[1].eps
+    def bn(prefix):
+        return [sd[prefix + ".weight"], sd[prefix + ".bias"], sd[prefix + ".running_mean"], sd[prefix + ".running_var"]]
+    parts = [sd["stem.0.weight"]] + bn("stem.1")
+    for i in range(blocks):
+        parts += [sd[f"body.{i}.c1.weight"]] + bn(f"body.{i}.b1")
+        parts += [sd[f"body.{i}.c2.weight"]] + bn(f"body.{i}.b2")
+    parts += [sd["head_p.0.weight"], sd["head_p.0.bias"], sd["head_p.3.weight"], sd["head_p.3.bias"]]
+    parts += [sd["head_v.0.weight"], sd["head_v.0.bias"], sd["head_v.4.weight"], sd["head_v.4.bias"]]
+    with open(path, "wb") as f:
+        f.write(b"HXCN")
+        f.write(struct.pack("<5If", 1, ch, blocks, head_ch, POLICY_LEN, eps))
+        for a in parts:
+            f.write(np.ascontiguousarray(a).tobytes())
+    print(f"[export] saved pure-Go weights to {path}")
+
 def main():
     ap = argparse.ArgumentParser()
     ap.add_argument("--pt", required=True, help="PyTorch权重 .pt（state_dict 或整模型）")
@@ -62,6 +86,7 @@ def main():
     ap.add_argument("--channels", type=int, default=0, help="主干通道，不填则自动推断")
     ap.add_argument("--blocks",   type=int, default=0, help="残差块数，不填则自动推断")
     ap.add_argument("--opset",    type=int, default=17)
+    ap.add_argument("--bin",      default="", help="同时导出纯 Go 前向用的权重（如 internal/game/assets/hex_cnn.bin）")
     args = ap.parse_args()
 
     obj = torch.load(args.pt, map_location="cpu")
@@ -99,12 +124,21 @@ def main():
         do_constant_folding=True, opset_version=args.opset
     )
     onnx_model = onnx.load(args.onnx); onnx.checker.check_model(onnx_model)
+    # 写几条元数据，Go 侧 Model.Info().Custom 能读到
+    for k, v in {"channels": ch, "blocks": blocks, "policy_len": POLICY_LEN}.items():
+        prop = onnx_model.metadata_props.add(); prop.key, prop.value = k, str(v)
+    onnx_model.producer_name = "export_hex_cnn_to_onnx"
+    onnx.save(onnx_model, args.onnx)
     print(f"[export] saved ONNX to {args.onnx}")
 
     # quick self-test (CPU EP)
     sess = ort.InferenceSession(args.onnx, providers=["CPUExecutionProvider"])
     out_logits, out_value = sess.run(["logits","value"], {"x": dummy.numpy().astype(np.float32)})
     print(f"[check] logits shape={np.array(out_logits).shape}, value shape={np.array(out_value).shape}")
+    assert np.array(out_logits).shape[-1] == POLICY_LEN, "策略头维度不对，Go 侧会拒绝加载"
+
+    if args.bin:
+        export_bin(net, args.bin)
 
 if __name__ == "__main__":
     main()
@@ -0,0 +1,164 @@
+// internal/game/bitboard.go
+package game
+
+import "math/bits"
+
+// maxBoardRadius 是位棋盘能容纳的最大半径：半径 4 共 61 格，正好塞进一个 uint64。
+const maxBoardRadius = 4
+
+// boardGeom 缓存某个半径下的格子编号与邻接掩码，所有同半径棋盘共享一份。
+// 格子编号与 AllCoords(radius) 的顺序一致（q 外层、r 内层）。
+type boardGeom struct {
+	radius    int
+	side      int                   // 2*radius+1，坐标网格边长
+	coords    []HexCoord            // 编号 → 坐标
+	index     []int8                // 网格下标 → 编号；-1 表示棋盘外
+	full      uint64                // 所有格子
+	outer     uint64                // 最外一圈
+	cloneMask []uint64              // 编号 → 距离 1 的邻格
+	jumpMask  []uint64              // 编号 → 距离 2 的跳跃落点
+	zidx      []int                 // 编号 → 最大半径下的编号（Zobrist 表下标）
+	sym       [NumSymmetries][]int8 // 对称 s 下编号 i 的像，见 symmetry.go
+}
+
+// geoms[r] 为半径 r 的几何表，init 时一次性生成，之后只读、可并发访问
+var geoms [maxBoardRadius + 1]*boardGeom
+
+func init() {
+	for r := 0; r <= maxBoardRadius; r++ {
+		geoms[r] = newBoardGeom(r)
+	}
+	big := geoms[maxBoardRadius]
+	for _, g := range geoms {
+		g.zidx = make([]int, len(g.coords))
+		for i, c := range g.coords {
+			g.zidx[i] = big.indexOf(c)
+		}
+	}
+}
+
+func newBoardGeom(radius int) *boardGeom {
+	g := &boardGeom{radius: radius, side: 2*radius + 1}
+	g.index = make([]int8, g.side*g.side)
+	for i := range g.index {
+		g.index[i] = -1
+	}
+	for q := -radius; q <= radius; q++ {
+		for r := -radius; r <= radius; r++ {
+			if abs(q)+abs(r)+abs(-q-r) <= 2*radius {
+				g.index[g.slot(HexCoord{q, r})] = int8(len(g.coords))
+				g.coords = append(g.coords, HexCoord{q, r})
+			}
+		}
+	}
+
+	n := len(g.coords)
+	g.cloneMask = make([]uint64, n)
+	g.jumpMask = make([]uint64, n)
+	for i, c := range g.coords {
+		g.full |= 1 << uint(i)
+		if isOuter(c, radius) {
+			g.outer |= 1 << uint(i)
+		}
+		for j, o := range g.coords {
+			switch HexDist(c, o) {
+			case 1:
+				g.cloneMask[i] |= 1 << uint(j)
+			case 2:
+				g.jumpMask[i] |= 1 << uint(j)
+			}
+		}
+	}
+	g.initSymmetries()
+	return g
+}
+
+// geomFor 返回半径对应的几何表；超出位棋盘容量直接 panic。
+func geomFor(radius int) *boardGeom {
+	if radius < 0 || radius > maxBoardRadius {
+		panic("game: unsupported board radius")
+	}
+	return geoms[radius]
+}
+
+func (g *boardGeom) slot(c HexCoord) int {
+	return (c.Q+g.radius)*g.side + (c.R + g.radius)
+}
+
+// indexOf 返回坐标编号，棋盘外返回 -1
+func (g *boardGeom) indexOf(c HexCoord) int {
+	if abs(c.Q) > g.radius || abs(c.R) > g.radius || abs(c.Q+c.R) > g.radius {
+		return -1
+	}
+	return int(g.index[g.slot(c)])
+}
+
+// mask 返回状态 s 对应的格子集合
+func (b *Board) mask(s CellState) uint64 {
+	switch s {
+	case PlayerA:
+		return b.bbA
+	case PlayerB:
+		return b.bbB
+	case Blocked:
+		return b.bbBlocked
+	case Empty:
+		return b.geom.full &^ (b.bbA | b.bbB | b.bbBlocked)
+	}
+	return 0
+}
+
+// cellAt 按编号读取格子状态
+func (b *Board) cellAt(i int) CellState {
+	bit := uint64(1) << uint(i)
+	switch {
+	case b.bbA&bit != 0:
+		return PlayerA
+	case b.bbB&bit != 0:
+		return PlayerB
+	case b.bbBlocked&bit != 0:
+		return Blocked
+	}
+	return Empty
+}
+
+// putAt 按编号写入格子状态（不处理 hash）
+func (b *Board) putAt(i int, s CellState) {
+	bit := uint64(1) << uint(i)
+	b.bbA &^= bit
+	b.bbB &^= bit
+	b.bbBlocked &^= bit
+	switch s {
+	case PlayerA:
+		b.bbA |= bit
+	case PlayerB:
+		b.bbB |= bit
+	case Blocked:
+		b.bbBlocked |= bit
+	}
+}
+
+// forEachBit 依编号从小到大遍历 m 中的每一位
+func forEachBit(m uint64, fn func(i int)) {
+	for m != 0 {
+		i := bits.TrailingZeros64(m)
+		m &= m - 1
+		fn(i)
+	}
+}
+
+// floodFill 在 within 内从 seed 出发按 6 邻接扩散，返回连通块
+func (g *boardGeom) floodFill(seed, within uint64) uint64 {
+	region := seed & within
+	frontier := region
+	for frontier != 0 {
+		var next uint64
+		forEachBit(frontier, func(i int) {
+			next |= g.cloneMask[i]
+		})
+		next &= within &^ region
+		region |= next
+		frontier = next
+	}
+	return region
+}
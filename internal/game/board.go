@@ -3,6 +3,7 @@ package game
 
 import (
 	"errors"
+	"math/bits"
 	"sync"
 )
 
@@ -30,11 +31,15 @@ var Directions = []HexCoord{
 
 // Board represents a hexagonal board of a given radius.
 // Coordinates satisfying |q| <= radius, |r| <= radius, |q+r| <= radius are valid.
+// 内部用位棋盘存储：每格一个 bit，编号见 boardGeom。
 type Board struct {
-	radius   int
-	cells    map[HexCoord]CellState
-	hash     uint64
-	LastMove Move
+	radius    int
+	geom      *boardGeom
+	bbA       uint64 // PlayerA 占据的格子
+	bbB       uint64 // PlayerB 占据的格子
+	bbBlocked uint64 // 障碍格
+	hash      uint64
+	LastMove  Move
 }
 
 var boardPool = sync.Pool{
@@ -43,11 +48,9 @@ var boardPool = sync.Pool{
 	},
 }
 
-var coordsCache = map[int][]HexCoord{} // 支持多半径
-
 func AllCoords(radius int) []HexCoord {
-	if coords, ok := coordsCache[radius]; ok {
-		return coords
+	if radius >= 0 && radius <= maxBoardRadius {
+		return geoms[radius].coords
 	}
 	var result []HexCoord
 	for q := -radius; q <= radius; q++ {
@@ -57,57 +60,37 @@ func AllCoords(radius int) []HexCoord {
 			}
 		}
 	}
-	coordsCache[radius] = result
 	return result
 }
 
 func acquireBoard(radius int) *Board {
 	b := boardPool.Get().(*Board)
-	b.radius = radius
-	// 只分配一份 cells，如果已有就复用
-	if b.cells == nil {
-		b.cells = make(map[HexCoord]CellState, len(AllCoords(radius)))
-	} else {
-		for k := range b.cells {
-			delete(b.cells, k)
-		}
-	}
-	b.hash = 0
+	*b = Board{radius: radius, geom: geomFor(radius)}
 	return b
 }
 
 func releaseBoard(b *Board) {
-	// 可选：清理 map 里的数据
-	for k := range b.cells {
-		delete(b.cells, k)
-	}
 	boardPool.Put(b)
 }
 
 func (b *Board) set(c HexCoord, s CellState) {
-	prev := b.cells[c]
+	i := b.geom.indexOf(c)
+	prev := b.cellAt(i)
 	if prev == s {
 		return
 	}
-	b.hash ^= zobristKey(c, prev) // 移除旧状态
-	b.cells[c] = s
-	b.hash ^= zobristKey(c, s) // 加入新状态
+	b.hash ^= b.geom.zobristAt(i, prev) // 移除旧状态
+	b.putAt(i, s)
+	b.hash ^= b.geom.zobristAt(i, s) // 加入新状态
 }
 
 // NewBoard creates and initializes a new board with the given radius.
+// radius 最大为 maxBoardRadius，超出会 panic。
 func NewBoard(radius int) *Board {
-	b := &Board{
+	return &Board{
 		radius: radius,
-		cells:  make(map[HexCoord]CellState),
-	}
-	for q := -radius; q <= radius; q++ {
-		for r := -radius; r <= radius; r++ {
-			if abs(q)+abs(r)+abs(-q-r) <= 2*radius {
-				b.cells[HexCoord{q, r}] = Empty
-			}
-		}
+		geom:   geomFor(radius),
 	}
-	return b
 }
 
 // InBounds returns true if coord c is within the board's radius.
@@ -123,15 +106,16 @@ func (b *Board) Get(c HexCoord) CellState {
 	if !b.InBounds(c) {
 		return Blocked
 	}
-	return b.cells[c]
+	return b.cellAt(b.geom.indexOf(c))
 }
 
 // Set updates the cell state at coord c. Returns an error if c is out of bounds.
+// 哈希随之增量更新。
 func (b *Board) Set(c HexCoord, state CellState) error {
 	if !b.InBounds(c) {
 		return errors.New("coordinate out of bounds")
 	}
-	b.cells[c] = state
+	b.set(c, state)
 	return nil
 }
 
@@ -148,7 +132,7 @@ func (b *Board) Neighbors(c HexCoord) []HexCoord {
 }
 
 func (b *Board) AllCoords() []HexCoord {
-	return AllCoords(b.radius)
+	return b.geom.coords
 }
 
 // AllCoords returns a slice of all coordinates on the board.
@@ -170,11 +154,7 @@ func abs(x int) int {
 
 func (b *Board) Clone() *Board {
 	nb := acquireBoard(b.radius)
-	for coord, state := range b.cells {
-		nb.cells[coord] = state
-	}
-	nb.hash = b.hash
-	nb.LastMove = b.LastMove
+	*nb = *b
 	return nb
 }
 
@@ -185,13 +165,11 @@ func (b *Board) applyMove(m Move, player CellState) (infected int, undo func())
 		prev CellState
 	}, 0, 8)
 	set := func(c HexCoord, s CellState) {
-		prev := b.cells[c]
+		prev := b.Get(c)
 		if prev == s {
 			return
 		}
-		b.hash ^= zobristKey(c, prev) // remove old
-		b.cells[c] = s
-		b.hash ^= zobristKey(c, s) // add new
+		b.set(c, s)
 		changed = append(changed, struct {
 			c    HexCoord
 			prev CellState
@@ -215,13 +193,7 @@ func (b *Board) Hash() uint64 {
 
 // CountPieces 统计棋盘上 pl 方棋子数量
 func (b *Board) CountPieces(pl CellState) int {
-	n := 0
-	for _, c := range b.AllCoords() {
-		if b.Get(c) == pl {
-			n++
-		}
-	}
-	return n
+	return bits.OnesCount64(b.mask(pl))
 }
 
 func (b *Board) ToFeature(side CellState) []float32 {
@@ -0,0 +1,412 @@
+// internal/game/book.go
+package game
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math/rand"
+	"os"
+	"sort"
+)
+
+// 开局库：自对弈对局里每个开局局面下各走法的胜/和/负统计，
+// 引擎搜索前先查库，按战绩加权随机挑一步。
+//
+// 局面按对称规范化后的哈希 ^ 行棋方存（见 CanonicalKey），
+// 走法也存成规范朝向，查库时再变换回当前棋盘的朝向。
+
+// BookMove 库里某局面下的一步及走这步一方的战绩
+type BookMove struct {
+	Move   Move
+	Wins   uint32
+	Draws  uint32
+	Losses uint32
+}
+
+// Games 这步一共出现在几局里
+func (m BookMove) Games() uint32 { return m.Wins + m.Draws + m.Losses }
+
+// Score 得分率，和棋算半分；没有对局时为 0
+func (m BookMove) Score() float64 {
+	g := m.Games()
+	if g == 0 {
+		return 0
+	}
+	return (float64(m.Wins) + float64(m.Draws)/2) / float64(g)
+}
+
+// weight 加权随机用的权重：赢一局 2、和一局 1，输的走法不会被选中
+func (m BookMove) weight() uint64 { return 2*uint64(m.Wins) + uint64(m.Draws) }
+
+// bookEntry 一个规范局面：最早在第几手出现，以及各走法（规范朝向，packMove 编码）
+type bookEntry struct {
+	ply   int
+	moves []bookMoveRec
+}
+
+type bookMoveRec struct {
+	move                packedMove
+	wins, draws, losses uint32
+}
+
+// BookOptions 查库参数；零值即不限制
+type BookOptions struct {
+	MaxPly   int // 只在第 MaxPly 手之前查库（按建库时记下的手数）；<=0 不限
+	MinGames int // 对局数少于它的走法不参与挑选
+}
+
+// Book 开局库。建库（Add/AddGame/Merge）不能并发；建好后只读，可以多个引擎同时查。
+type Book struct {
+	entries map[uint64]*bookEntry
+}
+
+// NewBook 空库
+func NewBook() *Book {
+	return &Book{entries: make(map[uint64]*bookEntry)}
+}
+
+// Len 库里的局面数
+func (bk *Book) Len() int { return len(bk.entries) }
+
+// canonicalMove 把走法变换到规范朝向。局面自身对称时几个对称都能当规范朝向，
+// 取编码最小的那个像，这样互相对称的走法落在同一条记录上。
+func canonicalMove(mv Move, ties uint16) packedMove {
+	var best packedMove
+	for s := Symmetry(0); s < NumSymmetries; s++ {
+		if ties&(1<<s) == 0 {
+			continue
+		}
+		if p := packMove(s.ApplyMove(mv)); best == 0 || p < best {
+			best = p
+		}
+	}
+	return best
+}
+
+// Add 记一条样本：第 ply 手、side 在 b 上走了 mv，整局 winner 获胜（Empty 为和棋）
+func (bk *Book) Add(b *Board, side CellState, ply int, mv Move, winner CellState) {
+	key, ties := canonicalKey(b, side)
+	pm := canonicalMove(mv, ties)
+	if pm == 0 {
+		return
+	}
+	e := bk.entries[key]
+	if e == nil {
+		e = &bookEntry{ply: ply}
+		bk.entries[key] = e
+	}
+	if ply < e.ply {
+		e.ply = ply
+	}
+	rec := e.find(pm)
+	switch winner {
+	case side:
+		rec.wins++
+	case Opponent(side):
+		rec.losses++
+	default:
+		rec.draws++
+	}
+}
+
+// find 取走法记录，没有就追加一条
+func (e *bookEntry) find(pm packedMove) *bookMoveRec {
+	for i := range e.moves {
+		if e.moves[i].move == pm {
+			return &e.moves[i]
+		}
+	}
+	e.moves = append(e.moves, bookMoveRec{move: pm})
+	return &e.moves[len(e.moves)-1]
+}
+
+// AddGame 从标准开局（半径 4）起重放一局，把前 maxPly 手记进库；maxPly<=0 整局都记。
+// 胜负按重放到最后的局面判：已终局用 Winner，否则比子数。
+func (bk *Book) AddGame(moves []Move, maxPly int) error {
+	gs := NewGameState(4)
+	type sample struct {
+		b    *Board
+		side CellState
+		mv   Move
+	}
+	var samples []sample
+	for i, mv := range moves {
+		if gs.GameOver {
+			return fmt.Errorf("第 %d 手之前对局已结束", i)
+		}
+		if !isLegal(gs.Board, mv, gs.CurrentPlayer) {
+			return fmt.Errorf("第 %d 手 %v 不合法", i, mv)
+		}
+		if maxPly <= 0 || i < maxPly {
+			samples = append(samples, sample{cloneBoard(gs.Board), gs.CurrentPlayer, mv})
+		}
+		gs.MakeMove(mv)
+	}
+	winner := gs.Winner
+	if !gs.GameOver {
+		switch a, b := gs.Board.CountPieces(PlayerA), gs.Board.CountPieces(PlayerB); {
+		case a > b:
+			winner = PlayerA
+		case b > a:
+			winner = PlayerB
+		default:
+			winner = Empty
+		}
+	}
+	for i, s := range samples {
+		bk.Add(s.b, s.side, i, s.mv, winner)
+	}
+	return nil
+}
+
+// Merge 把 o 的统计加进来；同一局面取较早的手数
+func (bk *Book) Merge(o *Book) {
+	for key, oe := range o.entries {
+		e := bk.entries[key]
+		if e == nil {
+			e = &bookEntry{ply: oe.ply}
+			bk.entries[key] = e
+		}
+		if oe.ply < e.ply {
+			e.ply = oe.ply
+		}
+		for _, om := range oe.moves {
+			rec := e.find(om.move)
+			rec.wins += om.wins
+			rec.draws += om.draws
+			rec.losses += om.losses
+		}
+	}
+}
+
+// Prune 删掉对局数少于 minGames 的走法，走法删光的局面一并删除；返回删掉的局面数
+func (bk *Book) Prune(minGames int) int {
+	removed := 0
+	for key, e := range bk.entries {
+		kept := e.moves[:0]
+		for _, m := range e.moves {
+			if int(m.wins+m.draws+m.losses) >= minGames {
+				kept = append(kept, m)
+			}
+		}
+		e.moves = kept
+		if len(kept) == 0 {
+			delete(bk.entries, key)
+			removed++
+		}
+	}
+	return removed
+}
+
+// Lookup 查 side 在 b 上的库内走法（已变换回 b 的朝向），按对局数从多到少排；
+// ply 是该局面最早出现在第几手
+func (bk *Book) Lookup(b *Board, side CellState) (moves []BookMove, ply int, ok bool) {
+	key, ties := canonicalKey(b, side)
+	e := bk.entries[key]
+	if e == nil {
+		return nil, 0, false
+	}
+	inv := firstSym(ties).Inverse()
+	for _, m := range e.moves {
+		mv, ok := m.move.unpack()
+		if !ok {
+			continue
+		}
+		mv = inv.ApplyMove(mv)
+		if !isLegal(b, mv, side) {
+			continue // 哈希撞了，或库是别的棋盘建的
+		}
+		moves = append(moves, BookMove{Move: mv, Wins: m.wins, Draws: m.draws, Losses: m.losses})
+	}
+	sort.SliceStable(moves, func(i, j int) bool { return moves[i].Games() > moves[j].Games() })
+	return moves, e.ply, len(moves) > 0
+}
+
+// Pick 按 opts 查库并加权随机挑一步（权重见 BookMove.weight）；
+// 不在库里、超出 MaxPly 或所有走法都只输不赢时 ok=false，交给搜索
+func (bk *Book) Pick(b *Board, side CellState, opts BookOptions, r *rand.Rand) (Move, bool) {
+	moves, ply, ok := bk.Lookup(b, side)
+	if !ok || (opts.MaxPly > 0 && ply >= opts.MaxPly) {
+		return Move{}, false
+	}
+	var total uint64
+	for _, m := range moves {
+		if int(m.Games()) >= opts.MinGames {
+			total += m.weight()
+		}
+	}
+	if total == 0 {
+		return Move{}, false
+	}
+	x := uint64(r.Int63n(int64(total)))
+	for _, m := range moves {
+		if int(m.Games()) < opts.MinGames {
+			continue
+		}
+		if x < m.weight() {
+			return m.Move, true
+		}
+		x -= m.weight()
+	}
+	return Move{}, false
+}
+
+// BookPosition Each 遍历时给出的一个局面；Moves 是规范朝向
+type BookPosition struct {
+	Key   uint64
+	Ply   int
+	Moves []BookMove
+}
+
+// Each 按手数、键的顺序遍历全库（cmd/book dump 用）
+func (bk *Book) Each(fn func(p BookPosition)) {
+	keys := make([]uint64, 0, len(bk.entries))
+	for k := range bk.entries {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		ei, ej := bk.entries[keys[i]], bk.entries[keys[j]]
+		if ei.ply != ej.ply {
+			return ei.ply < ej.ply
+		}
+		return keys[i] < keys[j]
+	})
+	for _, k := range keys {
+		e := bk.entries[k]
+		p := BookPosition{Key: k, Ply: e.ply}
+		for _, m := range e.moves {
+			mv, _ := m.move.unpack()
+			p.Moves = append(p.Moves, BookMove{Move: mv, Wins: m.wins, Draws: m.draws, Losses: m.losses})
+		}
+		fn(p)
+	}
+}
+
+// 文件格式 v1（小端）：
+//
+//	"HXBK" | u32 版本 | u32 局面数
+//	每个局面：u64 键 | u16 手数 | u16 走法数 | 每个走法 u16 packMove | u32 胜 | u32 和 | u32 负
+//	u32 CRC32(IEEE)，覆盖上面从 "HXBK" 开始的全部字节
+//
+// 键依赖 Zobrist 表，zobristSeed 一改旧库就全部作废。
+const (
+	bookMagic   = "HXBK"
+	bookVersion = 1
+)
+
+var ErrBookChecksum = errors.New("book: 校验和不符，文件损坏或被截断")
+
+// LoadBook 读开局库文件
+func LoadBook(path string) (*Book, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	bk, err := ReadBook(bufio.NewReader(f))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return bk, nil
+}
+
+// ReadBook 读 v1 格式并校验 CRC；多余的尾部字节也算错
+func ReadBook(r io.Reader) (*Book, error) {
+	crc := crc32.NewIEEE()
+	tr := io.TeeReader(r, crc)
+
+	var hdr struct {
+		Magic   [4]byte
+		Version uint32
+		Count   uint32
+	}
+	if err := binary.Read(tr, binary.LittleEndian, &hdr); err != nil {
+		return nil, fmt.Errorf("book: 读文件头: %w", err)
+	}
+	if string(hdr.Magic[:]) != bookMagic {
+		return nil, errors.New("book: 不是 HXBK 文件")
+	}
+	if hdr.Version != bookVersion {
+		return nil, fmt.Errorf("book: 不支持的版本 %d（需要 %d）", hdr.Version, bookVersion)
+	}
+
+	bk := NewBook()
+	for i := uint32(0); i < hdr.Count; i++ {
+		var ph struct {
+			Key   uint64
+			Ply   uint16
+			Moves uint16
+		}
+		if err := binary.Read(tr, binary.LittleEndian, &ph); err != nil {
+			return nil, fmt.Errorf("book: 读第 %d 个局面: %w", i, err)
+		}
+		e := &bookEntry{ply: int(ph.Ply), moves: make([]bookMoveRec, ph.Moves)}
+		for j := range e.moves {
+			var m struct {
+				Move                uint16
+				Wins, Draws, Losses uint32
+			}
+			if err := binary.Read(tr, binary.LittleEndian, &m); err != nil {
+				return nil, fmt.Errorf("book: 读第 %d 个局面的走法: %w", i, err)
+			}
+			e.moves[j] = bookMoveRec{packedMove(m.Move), m.Wins, m.Draws, m.Losses}
+		}
+		bk.entries[ph.Key] = e
+	}
+
+	sum := crc.Sum32()
+	var want uint32
+	if err := binary.Read(r, binary.LittleEndian, &want); err != nil {
+		return nil, fmt.Errorf("book: 读校验和: %w", err)
+	}
+	if want != sum {
+		return nil, ErrBookChecksum
+	}
+	if k, _ := io.Copy(io.Discard, r); k != 0 {
+		return nil, fmt.Errorf("book: 文件末尾多出 %d 字节", k)
+	}
+	return bk, nil
+}
+
+// Write 按 v1 格式写出（局面按键排序，同一个库写出的字节总是一样），ReadBook 能原样读回
+func (bk *Book) Write(w io.Writer) error {
+	keys := make([]uint64, 0, len(bk.entries))
+	for k := range bk.entries {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	var buf bytes.Buffer
+	buf.WriteString(bookMagic)
+	_ = binary.Write(&buf, binary.LittleEndian, []uint32{bookVersion, uint32(len(keys))})
+	for _, k := range keys {
+		e := bk.entries[k]
+		ply := e.ply
+		if ply > 0xffff {
+			ply = 0xffff
+		}
+		_ = binary.Write(&buf, binary.LittleEndian, k)
+		_ = binary.Write(&buf, binary.LittleEndian, []uint16{uint16(ply), uint16(len(e.moves))})
+		for _, m := range e.moves {
+			_ = binary.Write(&buf, binary.LittleEndian, uint16(m.move))
+			_ = binary.Write(&buf, binary.LittleEndian, []uint32{m.wins, m.draws, m.losses})
+		}
+	}
+	_ = binary.Write(&buf, binary.LittleEndian, crc32.ChecksumIEEE(buf.Bytes()))
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// Save 写到文件
+func (bk *Book) Save(path string) error {
+	var buf bytes.Buffer
+	if err := bk.Write(&buf); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
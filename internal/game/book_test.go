@@ -0,0 +1,137 @@
+package game
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+// randomGame 从标准开局随机走 n 手（提前终局就停）
+func randomGame(r *rand.Rand, n int) []Move {
+	gs := NewGameState(4)
+	var moves []Move
+	for i := 0; i < n && !gs.GameOver; i++ {
+		mvs := GenerateMoves(gs.Board, gs.CurrentPlayer)
+		mv := mvs[r.Intn(len(mvs))]
+		gs.MakeMove(mv)
+		moves = append(moves, mv)
+	}
+	return moves
+}
+
+func TestBookFileRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(5))
+	bk := NewBook()
+	for i := 0; i < 20; i++ {
+		if err := bk.AddGame(randomGame(r, 30), 10); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var buf bytes.Buffer
+	if err := bk.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadBook(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Len() != bk.Len() {
+		t.Fatalf("读回 %d 个局面，写出 %d 个", got.Len(), bk.Len())
+	}
+	var again bytes.Buffer
+	got.Write(&again)
+	if !bytes.Equal(buf.Bytes(), again.Bytes()) {
+		t.Fatal("读回再写出的字节应与原文件一致")
+	}
+
+	data := buf.Bytes()
+	data[len(data)/2] ^= 1
+	if _, err := ReadBook(bytes.NewReader(data)); !errors.Is(err, ErrBookChecksum) {
+		t.Fatalf("改坏一个字节应报校验和错误，得到 %v", err)
+	}
+}
+
+// TestBookSymmetricLookup 对称的局面共用一条记录，查出来的着法变换回当前朝向
+func TestBookSymmetricLookup(t *testing.T) {
+	r := rand.New(rand.NewSource(11))
+	moves := randomGame(r, 8)
+	bk := NewBook()
+	if err := bk.AddGame(moves, 0); err != nil {
+		t.Fatal(err)
+	}
+	gs := NewGameState(4)
+	for _, mv := range moves[:6] {
+		gs.MakeMove(mv)
+	}
+	want := moves[6]
+	if _, ties := gs.Board.canonicalHash(); ties != 1 {
+		t.Fatalf("测试局面应不自对称，ties=%b", ties)
+	}
+
+	// 转 120° 不动障碍格；镜像 + 转 60° 也不动
+	for _, s := range []Symmetry{2, 4, 7} {
+		tb := s.ApplyBoard(gs.Board)
+		if tb.bbBlocked != gs.Board.bbBlocked {
+			t.Fatalf("对称 %d 不该挪动障碍格", s)
+		}
+		got, ply, ok := bk.Lookup(tb, gs.CurrentPlayer)
+		if !ok || ply != 6 {
+			t.Fatalf("对称 %d: 查不到局面（ok=%v ply=%d）", s, ok, ply)
+		}
+		found := false
+		for _, m := range got {
+			if m.Move == s.ApplyMove(want) {
+				found = true
+			}
+		}
+		if !found {
+			t.Fatalf("对称 %d: 库里着法 %+v 里没有 %v", s, got, s.ApplyMove(want))
+		}
+	}
+
+	// 转 60° 会把障碍挪走，不是同一个局面
+	if _, _, ok := bk.Lookup(Symmetry(1).ApplyBoard(gs.Board), gs.CurrentPlayer); ok {
+		t.Fatal("挪动了障碍格的局面不应命中")
+	}
+}
+
+func TestBookPick(t *testing.T) {
+	gs := NewGameState(4)
+	mvs := GenerateMoves(gs.Board, PlayerA)
+	win, lose := mvs[0], mvs[len(mvs)-1]
+
+	bk := NewBook()
+	bk.Add(gs.Board, PlayerA, 0, lose, PlayerB)
+	r := rand.New(rand.NewSource(1))
+	if _, ok := bk.Pick(gs.Board, PlayerA, BookOptions{}, r); ok {
+		t.Fatal("只输不赢的走法不该被选中")
+	}
+
+	for i := 0; i < 3; i++ {
+		bk.Add(gs.Board, PlayerA, 0, win, PlayerA)
+	}
+	mv, ok := bk.Pick(gs.Board, PlayerA, BookOptions{}, r)
+	if !ok {
+		t.Fatal("应当从库里挑出一步")
+	}
+	if !isLegal(gs.Board, mv, PlayerA) {
+		t.Fatalf("库里挑出的 %v 不合法", mv)
+	}
+	if _, ok := bk.Pick(gs.Board, PlayerA, BookOptions{MinGames: 4}, r); ok {
+		t.Fatal("对局数不够 MinGames 的走法不参与挑选")
+	}
+	if _, ok := bk.Pick(gs.Board, PlayerA, BookOptions{MaxPly: 0}, r); !ok {
+		t.Fatal("MaxPly=0 不限手数")
+	}
+
+	e := NewEngine(EngineOptions{TTEntries: 1 << 12, Book: bk, BookSeed: 1})
+	res := e.Search(context.Background(), gs.Board, PlayerA, SearchLimits{MaxDepth: 3})
+	if !res.OK || !res.Book {
+		t.Fatalf("引擎应先查库，得到 %+v", res)
+	}
+	if res := e.Search(context.Background(), gs.Board, PlayerB, SearchLimits{MaxDepth: 1}); res.Book {
+		t.Fatal("库里没有的局面应当正常搜索")
+	}
+}
@@ -0,0 +1,286 @@
+// internal/game/cnn.go
+package game
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// 纯 Go 版 HexResNet 前向：onnxruntime 起不来（CI、没装运行库的 Linux 机器）时顶上。
+// 结构与 train_hex_cnn.py 一致：stem(conv3x3+BN+ReLU) → N 个残差块 → policy / value 两个头。
+// 权重由 export_hex_cnn_to_onnx.py --bin 导出，BN 在加载时折进卷积。
+//
+// 二进制格式（小端）：
+//
+//	"HXCN" | u32 版本 | u32 通道 | u32 残差块数 | u32 头部通道 | u32 策略长度 | f32 BN eps
+//	stem: conv w[ch,3,3,3]，BN γ/β/均值/方差 各 [ch]
+//	每个残差块: c1 w[ch,ch,3,3] + BN，c2 w[ch,ch,3,3] + BN
+//	head_p: conv w[hc,ch,1,1] b[hc]，linear w[P,hc*81] b[P]
+//	head_v: conv w[hc,ch,1,1] b[hc]，linear w[1,hc] b[1]
+const (
+	cnnMagic   = "HXCN"
+	cnnVersion = 1
+	cnnCells   = grid * grid
+)
+
+// cnnWeightsEnv 指向外部权重文件，优先于内嵌的 assets/hex_cnn.bin
+const cnnWeightsEnv = "HEX_CNN_WEIGHTS"
+
+// goModel 纯 Go 后端的 Model
+type goModel struct {
+	net  *cnnNet
+	info ModelInfo
+}
+
+func (m *goModel) Predict(b *Board, side CellState) ([]float32, float32, error) {
+	x := make([]float32, featPlanes*cnnCells)
+	encodeBoard(b, side, x)
+	p, v := m.net.forward(x, true)
+	return p, v, nil
+}
+
+func (m *goModel) predictValue(b *Board, side CellState) float32 {
+	x := make([]float32, featPlanes*cnnCells)
+	encodeBoard(b, side, x)
+	_, v := m.net.forward(x, false)
+	return v
+}
+
+func (m *goModel) Info() ModelInfo { return m.info }
+
+func (m *goModel) Close() error { return nil }
+
+// conv2d 9×9 平面上的卷积，k=3 时 padding=1；BN 已折进 w、b
+type conv2d struct {
+	in, out, k int
+	w          []float32 // [out][in][k][k]
+	b          []float32 // [out]
+}
+
+// cnnNet 加载好的网络，只读，可被多个 goroutine 同时使用
+type cnnNet struct {
+	ch, blocks, headCh, policyLen int
+
+	stem   conv2d
+	body   [][2]conv2d
+	pConv  conv2d
+	pW, pB []float32 // [policyLen][headCh*81]
+	vConv  conv2d
+	vW     []float32 // [headCh]
+	vB     float32
+}
+
+// weightReader 顺序读 float32，出错后后续读取都跳过，最后统一看 err
+type weightReader struct {
+	r   io.Reader
+	err error
+}
+
+func (wr *weightReader) floats(n int) []float32 {
+	out := make([]float32, n)
+	if wr.err == nil {
+		wr.err = binary.Read(wr.r, binary.LittleEndian, out)
+	}
+	return out
+}
+
+// convBN 读卷积（无偏置）+ BN，并把 BN 折进去：w' = w·γ/√(var+eps)，b' = β - mean·γ/√(var+eps)
+func (wr *weightReader) convBN(in, out, k int, eps float32) conv2d {
+	c := conv2d{in: in, out: out, k: k, w: wr.floats(out * in * k * k)}
+	gamma, beta, mean, variance := wr.floats(out), wr.floats(out), wr.floats(out), wr.floats(out)
+	c.b = make([]float32, out)
+	per := in * k * k
+	for o := 0; o < out; o++ {
+		s := gamma[o] / float32(math.Sqrt(float64(variance[o]+eps)))
+		for i := o * per; i < (o+1)*per; i++ {
+			c.w[i] *= s
+		}
+		c.b[o] = beta[o] - mean[o]*s
+	}
+	return c
+}
+
+// convBias 读带偏置的卷积
+func (wr *weightReader) convBias(in, out, k int) conv2d {
+	return conv2d{in: in, out: out, k: k, w: wr.floats(out * in * k * k), b: wr.floats(out)}
+}
+
+// loadCNN 按上面的格式读网络
+func loadCNN(r io.Reader) (*cnnNet, error) {
+	var hdr struct {
+		Magic                         [4]byte
+		Version                       uint32
+		Ch, Blocks, HeadCh, PolicyLen uint32
+		Eps                           float32
+	}
+	if err := binary.Read(r, binary.LittleEndian, &hdr); err != nil {
+		return nil, fmt.Errorf("读权重头: %w", err)
+	}
+	if string(hdr.Magic[:]) != cnnMagic || hdr.Version != cnnVersion {
+		return nil, fmt.Errorf("不是 v%d 的 %s 权重文件", cnnVersion, cnnMagic)
+	}
+	if hdr.PolicyLen != PolicyLen {
+		return nil, fmt.Errorf("权重策略头维度 %d，需要 %d（落点×%d 方向），请重新训练导出", hdr.PolicyLen, PolicyLen, MoveDirs)
+	}
+	if hdr.Ch == 0 || hdr.Ch > 1024 || hdr.Blocks > 64 || hdr.HeadCh == 0 || hdr.HeadCh > 1024 {
+		return nil, fmt.Errorf("权重头不合理: ch=%d blocks=%d head=%d", hdr.Ch, hdr.Blocks, hdr.HeadCh)
+	}
+
+	ch, hc := int(hdr.Ch), int(hdr.HeadCh)
+	n := &cnnNet{ch: ch, blocks: int(hdr.Blocks), headCh: hc, policyLen: PolicyLen}
+	wr := &weightReader{r: r}
+	n.stem = wr.convBN(featPlanes, ch, 3, hdr.Eps)
+	n.body = make([][2]conv2d, n.blocks)
+	for i := range n.body {
+		n.body[i][0] = wr.convBN(ch, ch, 3, hdr.Eps)
+		n.body[i][1] = wr.convBN(ch, ch, 3, hdr.Eps)
+	}
+	n.pConv = wr.convBias(ch, hc, 1)
+	n.pW, n.pB = wr.floats(PolicyLen*hc*cnnCells), wr.floats(PolicyLen)
+	n.vConv = wr.convBias(ch, hc, 1)
+	n.vW = wr.floats(hc)
+	n.vB = wr.floats(1)[0]
+	if wr.err != nil {
+		return nil, fmt.Errorf("读权重: %w", wr.err)
+	}
+	// 多出来的字节说明导出脚本和这里的顺序对不上
+	if k, _ := io.Copy(io.Discard, r); k != 0 {
+		return nil, fmt.Errorf("权重文件末尾多出 %d 字节", k)
+	}
+	return n, nil
+}
+
+// loadGoModel 读 opts.GoWeights / HEX_CNN_WEIGHTS 指向的文件，都没有就用内嵌的 assets/hex_cnn.bin
+func loadGoModel(opts ModelOptions) (Model, error) {
+	path := opts.GoWeights
+	if path == "" {
+		path = os.Getenv(cnnWeightsEnv)
+	}
+	var (
+		net *cnnNet
+		err error
+	)
+	if path != "" {
+		f, ferr := os.Open(path)
+		if ferr != nil {
+			return nil, ferr
+		}
+		defer f.Close()
+		net, err = loadCNN(bufio.NewReader(f))
+	} else {
+		data, rerr := modelFS.ReadFile("assets/hex_cnn.bin")
+		if rerr != nil {
+			return nil, fmt.Errorf("没有纯 Go 权重（assets/hex_cnn.bin 或 %s）: %w", cnnWeightsEnv, rerr)
+		}
+		path = "embedded"
+		net, err = loadCNN(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &goModel{net: net, info: ModelInfo{
+		Backend: "go", Source: path,
+		Custom: map[string]string{
+			"channels": fmt.Sprint(net.ch),
+			"blocks":   fmt.Sprint(net.blocks),
+		},
+	}}, nil
+}
+
+// apply 把 in [c.in][81] 卷到 out [c.out][81]
+func (c *conv2d) apply(in, out []float32, relu bool) {
+	for o := 0; o < c.out; o++ {
+		dst := out[o*cnnCells : (o+1)*cnnCells]
+		for i := range dst {
+			dst[i] = c.b[o]
+		}
+		for i := 0; i < c.in; i++ {
+			src := in[i*cnnCells : (i+1)*cnnCells]
+			if c.k == 1 {
+				w := c.w[o*c.in+i]
+				for j, x := range src {
+					dst[j] += w * x
+				}
+				continue
+			}
+			wk := c.w[(o*c.in+i)*9 : (o*c.in+i+1)*9]
+			for ky := 0; ky < 3; ky++ {
+				dy := ky - 1
+				for kx := 0; kx < 3; kx++ {
+					w := wk[ky*3+kx]
+					if w == 0 {
+						continue
+					}
+					dx := kx - 1
+					for y := max(0, -dy); y < min(grid, grid-dy); y++ {
+						row, srow := dst[y*grid:(y+1)*grid], src[(y+dy)*grid:(y+dy+1)*grid]
+						for x := max(0, -dx); x < min(grid, grid-dx); x++ {
+							row[x] += w * srow[x+dx]
+						}
+					}
+				}
+			}
+		}
+		if relu {
+			for i, v := range dst {
+				if v < 0 {
+					dst[i] = 0
+				}
+			}
+		}
+	}
+}
+
+// forward 输入 [3][9][9]（同 encodeBoard），返回 policy logits（wantPolicy=false 时为 nil）和 value
+func (n *cnnNet) forward(x []float32, wantPolicy bool) ([]float32, float32) {
+	cur := make([]float32, n.ch*cnnCells)
+	tmp := make([]float32, n.ch*cnnCells)
+	tmp2 := make([]float32, n.ch*cnnCells)
+	n.stem.apply(x, cur, true)
+	for i := range n.body {
+		n.body[i][0].apply(cur, tmp, true)
+		n.body[i][1].apply(tmp, tmp2, false)
+		for j, v := range tmp2 {
+			if v += cur[j]; v > 0 {
+				cur[j] = v
+			} else {
+				cur[j] = 0
+			}
+		}
+	}
+
+	head := make([]float32, n.headCh*cnnCells)
+
+	// value：1×1 卷积 + ReLU → 全局平均池化 → 线性 → tanh
+	n.vConv.apply(cur, head, true)
+	v := n.vB
+	for c := 0; c < n.headCh; c++ {
+		var s float32
+		for _, h := range head[c*cnnCells : (c+1)*cnnCells] {
+			s += h
+		}
+		v += n.vW[c] * s / cnnCells
+	}
+	value := float32(math.Tanh(float64(v)))
+	if !wantPolicy {
+		return nil, value
+	}
+
+	// policy：1×1 卷积 + ReLU → 按 (C,H,W) 展平 → 线性
+	n.pConv.apply(cur, head, true)
+	logits := make([]float32, n.policyLen)
+	in := len(head)
+	for i := range logits {
+		s := n.pB[i]
+		for j, w := range n.pW[i*in : (i+1)*in] {
+			s += w * head[j]
+		}
+		logits[i] = s
+	}
+	return logits, value
+}
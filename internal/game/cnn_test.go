@@ -0,0 +1,385 @@
+package game
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"testing"
+)
+
+// refCNN 未折叠 BN 的朴素实现，用来对照 cnnNet.forward；字段顺序即导出顺序
+type refCNN struct {
+	ch, blocks, hc int
+	eps            float32
+	tensors        [][]float32
+}
+
+func randomRefCNN(r *rand.Rand, ch, blocks, hc int) *refCNN {
+	n := &refCNN{ch: ch, blocks: blocks, hc: hc, eps: 1e-5}
+	add := func(size int, lo, hi float32) {
+		t := make([]float32, size)
+		for i := range t {
+			t[i] = lo + (hi-lo)*r.Float32()
+		}
+		n.tensors = append(n.tensors, t)
+	}
+	bn := func(c int) {
+		add(c, 0.5, 1.5)  // γ
+		add(c, -0.2, 0.2) // β
+		add(c, -0.2, 0.2) // 均值
+		add(c, 0.5, 2)    // 方差
+	}
+	add(ch*featPlanes*9, -0.5, 0.5)
+	bn(ch)
+	for i := 0; i < 2*blocks; i++ {
+		add(ch*ch*9, -0.3, 0.3)
+		bn(ch)
+	}
+	add(hc*ch, -0.5, 0.5)
+	add(hc, -0.1, 0.1)
+	add(PolicyLen*hc*cnnCells, -0.1, 0.1)
+	add(PolicyLen, -0.1, 0.1)
+	add(hc*ch, -0.5, 0.5)
+	add(hc, -0.1, 0.1)
+	add(hc, -0.5, 0.5)
+	add(1, -0.1, 0.1)
+	return n
+}
+
+func (n *refCNN) bytes() []byte {
+	var buf bytes.Buffer
+	buf.WriteString(cnnMagic)
+	_ = binary.Write(&buf, binary.LittleEndian, []uint32{cnnVersion, uint32(n.ch), uint32(n.blocks), uint32(n.hc), PolicyLen})
+	_ = binary.Write(&buf, binary.LittleEndian, n.eps)
+	for _, t := range n.tensors {
+		_ = binary.Write(&buf, binary.LittleEndian, t)
+	}
+	return buf.Bytes()
+}
+
+// refConv 直接按定义算 k×k 卷积（padding=k/2）
+func refConv(in []float32, cin int, w, bias []float32, cout, k int) []float32 {
+	out := make([]float32, cout*cnnCells)
+	for o := 0; o < cout; o++ {
+		for y := 0; y < grid; y++ {
+			for x := 0; x < grid; x++ {
+				var s float64
+				if bias != nil {
+					s = float64(bias[o])
+				}
+				for i := 0; i < cin; i++ {
+					for ky := 0; ky < k; ky++ {
+						for kx := 0; kx < k; kx++ {
+							yy, xx := y+ky-k/2, x+kx-k/2
+							if yy < 0 || yy >= grid || xx < 0 || xx >= grid {
+								continue
+							}
+							s += float64(w[((o*cin+i)*k+ky)*k+kx]) * float64(in[i*cnnCells+yy*grid+xx])
+						}
+					}
+				}
+				out[o*cnnCells+y*grid+x] = float32(s)
+			}
+		}
+	}
+	return out
+}
+
+func (n *refCNN) forward(x []float32) ([]float32, float32) {
+	t := n.tensors
+	next := func() []float32 { v := t[0]; t = t[1:]; return v }
+	bn := func(a []float32, c int) {
+		g, b, m, v := next(), next(), next(), next()
+		for o := 0; o < c; o++ {
+			for j := 0; j < cnnCells; j++ {
+				i := o*cnnCells + j
+				a[i] = (a[i]-m[o])/float32(math.Sqrt(float64(v[o]+n.eps)))*g[o] + b[o]
+			}
+		}
+	}
+	relu := func(a []float32) {
+		for i := range a {
+			a[i] = float32(math.Max(0, float64(a[i])))
+		}
+	}
+	cur := refConv(x, featPlanes, next(), nil, n.ch, 3)
+	bn(cur, n.ch)
+	relu(cur)
+	for i := 0; i < n.blocks; i++ {
+		y := refConv(cur, n.ch, next(), nil, n.ch, 3)
+		bn(y, n.ch)
+		relu(y)
+		y = refConv(y, n.ch, next(), nil, n.ch, 3)
+		bn(y, n.ch)
+		for j := range cur {
+			cur[j] += y[j]
+		}
+		relu(cur)
+	}
+	ph := refConv(cur, n.ch, next(), next(), n.hc, 1)
+	relu(ph)
+	pw, pb := next(), next()
+	logits := make([]float32, PolicyLen)
+	for i := range logits {
+		s := float64(pb[i])
+		for j, h := range ph {
+			s += float64(pw[i*len(ph)+j]) * float64(h)
+		}
+		logits[i] = float32(s)
+	}
+	vh := refConv(cur, n.ch, next(), next(), n.hc, 1)
+	relu(vh)
+	vw, vb := next(), next()
+	v := float64(vb[0])
+	for c := 0; c < n.hc; c++ {
+		var s float64
+		for j := 0; j < cnnCells; j++ {
+			s += float64(vh[c*cnnCells+j])
+		}
+		v += float64(vw[c]) * s / cnnCells
+	}
+	return logits, float32(math.Tanh(v))
+}
+
+// TestCNNMatchesReference 随机权重：折叠 BN 后的前向与朴素实现一致
+func TestCNNMatchesReference(t *testing.T) {
+	r := rand.New(rand.NewSource(11))
+	ref := randomRefCNN(r, 6, 2, 4)
+	net, err := loadCNN(bytes.NewReader(ref.bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gs := NewGameState(4)
+	for ply := 0; ply < 6 && !gs.GameOver; ply++ {
+		x := make([]float32, featPlanes*cnnCells)
+		encodeBoard(gs.Board, gs.CurrentPlayer, x)
+		wantP, wantV := ref.forward(x)
+		gotP, gotV := net.forward(x, true)
+		if d := math.Abs(float64(gotV - wantV)); d > 1e-4 {
+			t.Fatalf("ply %d: value %v，参考 %v", ply, gotV, wantV)
+		}
+		for i := range wantP {
+			if d := math.Abs(float64(gotP[i] - wantP[i])); d > 1e-3 {
+				t.Fatalf("ply %d: logit[%d]=%v，参考 %v", ply, i, gotP[i], wantP[i])
+			}
+		}
+		moves := GenerateMoves(gs.Board, gs.CurrentPlayer)
+		gs.MakeMove(moves[r.Intn(len(moves))])
+	}
+
+	// 截断或多余字节都要报错
+	data := ref.bytes()
+	if _, err := loadCNN(bytes.NewReader(data[:len(data)-4])); err == nil {
+		t.Fatal("截断的权重应报错")
+	}
+	if _, err := loadCNN(bytes.NewReader(append(data, 0))); err == nil {
+		t.Fatal("多余字节应报错")
+	}
+}
+
+// pb 手写的 protobuf 编码，只够拼一个 ONNX 模型（测试环境没有 onnx 库）
+type pb []byte
+
+func (p pb) varint(v uint64) pb {
+	for v >= 0x80 {
+		p = append(p, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(p, byte(v))
+}
+
+func (p pb) tag(field, wire int) pb     { return p.varint(uint64(field<<3 | wire)) }
+func (p pb) int(field int, v int64) pb  { return p.tag(field, 0).varint(uint64(v)) }
+func (p pb) msg(field int, b []byte) pb { return append(p.tag(field, 2).varint(uint64(len(b))), b...) }
+func (p pb) str(field int, s string) pb { return p.msg(field, []byte(s)) }
+func (p pb) f32(field int, f float32) pb {
+	return binary.LittleEndian.AppendUint32(p.tag(field, 5), math.Float32bits(f))
+}
+
+// onnx 把同一份权重按 export_hex_cnn_to_onnx.py 的网络结构拼成 ONNX（opset 13，BN 不折叠，交给 onnxruntime 算）。
+// 输入 x (N,3,9,9)，输出 logits (N,PolicyLen) 与 value (N,1)；字段号见 onnx.proto
+func (n *refCNN) onnx() []byte {
+	var graph pb // GraphProto: node=1 name=2 initializer=5 input=11 output=12
+	t := n.tensors
+	id := 0
+	param := func(dims ...int) string { // TensorProto: dims=1 data_type=2 name=8 raw_data=9
+		id++
+		name := fmt.Sprintf("w%d", id)
+		var tp pb
+		for _, d := range dims {
+			tp = tp.int(1, int64(d))
+		}
+		raw := make([]byte, 0, 4*len(t[0]))
+		for _, f := range t[0] {
+			raw = binary.LittleEndian.AppendUint32(raw, math.Float32bits(f))
+		}
+		t = t[1:]
+		graph = graph.msg(5, tp.int(2, 1).str(8, name).msg(9, raw))
+		return name
+	}
+	// NodeProto: input=1 output=2 op_type=4 attribute=5；AttributeProto: name=1 f=2 i=3 ints=8 type=20
+	node := func(op, out string, attrs []pb, inputs ...string) string {
+		if out == "" {
+			id++
+			out = fmt.Sprintf("t%d", id)
+		}
+		var np pb
+		for _, in := range inputs {
+			np = np.str(1, in)
+		}
+		np = np.str(2, out).str(4, op)
+		for _, a := range attrs {
+			np = np.msg(5, a)
+		}
+		graph = graph.msg(1, np)
+		return out
+	}
+	ints := func(name string, vs ...int64) pb {
+		a := pb(nil).str(1, name)
+		for _, v := range vs {
+			a = a.int(8, v)
+		}
+		return a.int(20, 7)
+	}
+	conv := func(x string, cin, cout, k int, bias bool) string {
+		in := []string{x, param(cout, cin, k, k)}
+		if bias {
+			in = append(in, param(cout))
+		}
+		p := int64(k / 2)
+		return node("Conv", "", []pb{ints("kernel_shape", int64(k), int64(k)), ints("pads", p, p, p, p)}, in...)
+	}
+	bn := func(x string, c int) string {
+		g := param(c)
+		b := param(c)
+		m := param(c)
+		v := param(c)
+		eps := pb(nil).str(1, "epsilon").f32(2, n.eps).int(20, 1)
+		return node("BatchNormalization", "", []pb{eps}, x, g, b, m, v)
+	}
+	flatten := []pb{pb(nil).str(1, "axis").int(3, 1).int(20, 2)}
+	transB := []pb{pb(nil).str(1, "transB").int(3, 1).int(20, 2)}
+
+	cur := node("Relu", "", nil, bn(conv("x", featPlanes, n.ch, 3, false), n.ch))
+	for i := 0; i < n.blocks; i++ {
+		y := node("Relu", "", nil, bn(conv(cur, n.ch, n.ch, 3, false), n.ch))
+		y = bn(conv(y, n.ch, n.ch, 3, false), n.ch)
+		cur = node("Relu", "", nil, node("Add", "", nil, cur, y))
+	}
+	ph := node("Flatten", "", flatten, node("Relu", "", nil, conv(cur, n.ch, n.hc, 1, true)))
+	pw := param(PolicyLen, n.hc*cnnCells)
+	node("Gemm", "logits", transB, ph, pw, param(PolicyLen))
+	vh := node("Relu", "", nil, conv(cur, n.ch, n.hc, 1, true))
+	vh = node("Flatten", "", flatten, node("GlobalAveragePool", "", nil, vh))
+	vw := param(1, n.hc)
+	node("Tanh", "value", nil, node("Gemm", "", transB, vh, vw, param(1)))
+
+	// ValueInfoProto: name=1 type=2；TypeProto.tensor_type=1 {elem_type=1 shape=2 {dim=1 {dim_value=1 | dim_param=2}}}
+	valueInfo := func(name string, dims ...int) pb {
+		shape := pb(nil).msg(1, pb(nil).str(2, "N"))
+		for _, d := range dims {
+			shape = shape.msg(1, pb(nil).int(1, int64(d)))
+		}
+		return pb(nil).str(1, name).msg(2, pb(nil).msg(1, pb(nil).int(1, 1).msg(2, shape)))
+	}
+	graph = graph.str(2, "hex_cnn_fixture").
+		msg(11, valueInfo("x", featPlanes, grid, grid)).
+		msg(12, valueInfo("logits", PolicyLen)).
+		msg(12, valueInfo("value", 1))
+	// ModelProto: ir_version=1 producer_name=2 graph=7 opset_import=8 {domain=1 version=2}
+	return pb(nil).int(1, 7).str(2, "cnn_test").msg(7, graph).msg(8, pb(nil).str(1, "").int(2, 13))
+}
+
+// compareModels 随机下一局，两个模型在每个局面上的输出应一致
+func compareModels(t *testing.T, got, want Model) {
+	t.Helper()
+	r := rand.New(rand.NewSource(3))
+	gs := NewGameState(4)
+	for ply := 0; ply < 20 && !gs.GameOver; ply++ {
+		gotP, gotV, err := got.Predict(gs.Board, gs.CurrentPlayer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wantP, wantV, err := want.Predict(gs.Board, gs.CurrentPlayer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d := math.Abs(float64(gotV - wantV)); d > 1e-3 {
+			t.Fatalf("ply %d: value %v，onnxruntime %v", ply, gotV, wantV)
+		}
+		for i := range wantP {
+			if d := math.Abs(float64(gotP[i] - wantP[i])); d > 1e-2 {
+				t.Fatalf("ply %d: logit[%d]=%v，onnxruntime %v", ply, i, gotP[i], wantP[i])
+			}
+		}
+		moves := GenerateMoves(gs.Board, gs.CurrentPlayer)
+		gs.MakeMove(moves[r.Intn(len(moves))])
+	}
+}
+
+// TestCNNMatchesONNX 纯 Go 前向与 onnxruntime 的输出一致。同一份随机权重各导出一份 HXCN 和 ONNX，
+// 只要有 onnxruntime 共享库就能跑；另外有导出的 hex_cnn.bin（或 HEX_CNN_WEIGHTS）时再对一遍真实模型。
+func TestCNNMatchesONNX(t *testing.T) {
+	if err := initORT(); err != nil {
+		t.Skipf("onnxruntime 不可用: %v", err)
+	}
+	ref := randomRefCNN(rand.New(rand.NewSource(5)), 6, 2, 4)
+	om, err := LoadModel(ModelOptions{Backend: "onnx", Data: ref.onnx()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer om.Close()
+	net, err := loadCNN(bytes.NewReader(ref.bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareModels(t, &goModel{net: net}, om)
+
+	real, err := LoadModel(ModelOptions{Backend: "onnx"})
+	if err != nil {
+		t.Skipf("没有内嵌的 ONNX 模型: %v", err)
+	}
+	defer real.Close()
+	gm, err := LoadModel(ModelOptions{Backend: "go"})
+	if err != nil {
+		t.Skipf("没有真实模型的纯 Go 权重: %v", err)
+	}
+	compareModels(t, gm, real)
+}
+
+// TestGoModelAsDefault 纯 Go 权重文件走 LoadModel，设为全局模型后 EvaluateNN/PolicyNN 都用它
+func TestGoModelAsDefault(t *testing.T) {
+	ref := randomRefCNN(rand.New(rand.NewSource(4)), 4, 1, 2)
+	path := t.TempDir() + "/w.bin"
+	if err := os.WriteFile(path, ref.bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m, err := LoadModel(ModelOptions{Backend: "go", GoWeights: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info := m.Info(); info.Backend != "go" || info.Source != path {
+		t.Fatalf("Info = %+v", info)
+	}
+	SetDefaultModel(m)
+	defer SetDefaultModel(nil)
+
+	b := NewBoard(4)
+	p, v, err := m.Predict(b, PlayerA)
+	if err != nil || len(p) != PolicyLen {
+		t.Fatalf("Predict: len=%d err=%v", len(p), err)
+	}
+	if got := EvaluateNN(b, PlayerA); got != int(v*100) {
+		t.Fatalf("EvaluateNN = %d，期望 %d", got, int(v*100))
+	}
+	if logits, err := PolicyNN(b, PlayerA); err != nil || logits[7] != p[7] {
+		t.Fatalf("PolicyNN 没走全局模型: %v", err)
+	}
+
+	if _, err := LoadModel(ModelOptions{Backend: "tpu"}); err == nil {
+		t.Fatal("未知后端应报错")
+	}
+}
@@ -5,8 +5,21 @@ const (
 	GridSize  = 9 // 把半径4六角映射到9×9
 	PlaneCnt  = 3 // [我方, 对方, Blocked]
 	TensorLen = PlaneCnt * GridSize * GridSize
+
+	// 策略头按“落点 × 走法方向”编码：每个落点 18 个方向（6 克隆 + 12 跳跃），
+	// 同一落点的克隆和各个跳跃各有自己的 logit。
+	MoveDirs  = 18
+	PolicyLen = GridSize * GridSize * MoveDirs // 1458
 )
 
+// moveDirs 是策略编码里的方向表（To - From）：前 6 个即 cloneDirs，后 12 个即 jumpDirs。
+// 顺序是 ONNX 契约的一部分，train_hex_cnn.py 里的 MOVE_DIRS 必须与之一致。
+var moveDirs = func() (d [MoveDirs]HexCoord) {
+	copy(d[:], cloneDirs)
+	copy(d[len(cloneDirs):], jumpDirs)
+	return
+}()
+
 // EncodeBoardTensor 把棋盘即时编码成 [243]float32 张量
 func EncodeBoardTensor(b *Board, me CellState) [TensorLen]float32 {
 	var t [TensorLen]float32
@@ -31,5 +44,46 @@ func EncodeBoardTensor(b *Board, me CellState) [TensorLen]float32 {
 	return t
 }
 
-// AxialToIndex 把落子坐标映射到 0..80 的 move 索引
+// DecodeBoardTensor EncodeBoardTensor 的逆：按 me 视角的张量还原出半径 4 的棋盘。
+// 张量里没有上一步，LastMove 为零值，需要的话由调用方补上。
+func DecodeBoardTensor(t []float32, me CellState) *Board {
+	b := NewBoard(4)
+	for _, c := range b.AllCoords() {
+		idx := AxialToIndex(c)
+		switch {
+		case t[idx] != 0:
+			b.set(c, me)
+		case t[GridSize*GridSize+idx] != 0:
+			b.set(c, Opponent(me))
+		case t[2*GridSize*GridSize+idx] != 0:
+			b.set(c, Blocked)
+		}
+	}
+	return b
+}
+
+// AxialToIndex 把坐标映射到 9×9 平面的 0..80 索引
 func AxialToIndex(c HexCoord) int { return (c.R+4)*GridSize + (c.Q + 4) }
+
+// MoveToPolicyIndex 把走法映射到 0..PolicyLen-1 的策略索引：AxialToIndex(To)*MoveDirs + 方向号。
+// 不是克隆/跳跃（距离不为 1、2）或落点超出 9×9 时返回 -1。
+func MoveToPolicyIndex(m Move) int {
+	if abs(m.To.Q) > 4 || abs(m.To.R) > 4 {
+		return -1
+	}
+	d := HexCoord{m.To.Q - m.From.Q, m.To.R - m.From.R}
+	for k, md := range moveDirs {
+		if md == d {
+			return AxialToIndex(m.To)*MoveDirs + k
+		}
+	}
+	return -1
+}
+
+// PolicyIndexToMove 是 MoveToPolicyIndex 的逆映射（不检查是否在棋盘内）
+func PolicyIndexToMove(i int) Move {
+	cell, k := i/MoveDirs, i%MoveDirs
+	to := HexCoord{Q: cell%GridSize - 4, R: cell/GridSize - 4}
+	d := moveDirs[k]
+	return Move{From: HexCoord{to.Q - d.Q, to.R - d.R}, To: to}
+}
@@ -0,0 +1,285 @@
+// internal/game/endgame.go
+package game
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+const (
+	// defaultEndgameEmpties 空格数不超过它时 Search 先尝试精确求解。
+	// 跳跃让残局分支很宽：半径 4 的随机残局 3 空基本秒解，4 空有一部分解不出来
+	defaultEndgameEmpties = 4
+	// endgameTTEntries 残局专用置换表大小；分数是精确子数差，和评估分不能混用一张表
+	endgameTTEntries = 1 << 18
+	// endgameTimeCap 调用方不限时（固定深度、无预算的 Search）时求解器的时间上限
+	endgameTimeCap = 500 * time.Millisecond
+)
+
+// EndgameOutcome 残局的理论结果
+type EndgameOutcome int8
+
+const (
+	EndgameLoss EndgameOutcome = -1
+	EndgameDraw EndgameOutcome = 0
+	EndgameWin  EndgameOutcome = 1
+)
+
+func (o EndgameOutcome) String() string {
+	switch o {
+	case EndgameWin:
+		return "win"
+	case EndgameLoss:
+		return "loss"
+	}
+	return "draw"
+}
+
+// EndgameResult 残局求解结果
+type EndgameResult struct {
+	Move    Move
+	Outcome EndgameOutcome
+	Margin  int    // 双方最优应对下的终局子数差（player 视角）
+	PV      []Move // 以 Move 开头的最优走法序列；置换表截断时可能不到终局
+	Nodes   uint64
+	Time    time.Duration
+	Solved  bool // false 表示被取消或在 endgameMaxPly 内证不出来，其余字段无意义
+	OK      bool // false 表示无合法走法
+}
+
+// endgameEmpties 返回生效的残局阈值；<0 表示关闭
+func (e *Engine) endgameEmpties() int {
+	if e.opts.EndgameEmpties == 0 {
+		return defaultEndgameEmpties
+	}
+	return e.opts.EndgameEmpties
+}
+
+// inEndgame 判断 root 是否已进入求解器的空格阈值
+func (e *Engine) inEndgame(root *Board) bool {
+	return root.CountPieces(Empty) <= e.endgameEmpties()
+}
+
+// SolveEndgame 把局面一直下到终局，给出精确的胜负与子数差。
+// 终局规则同 GameState.MakeMove：棋盘下满即结束；一方无棋可走时，剩余空格全部归对方。
+// 搜索量随空格数指数增长，只应在残局调用；ctx 取消或证不出来时返回 Solved=false。
+func (e *Engine) SolveEndgame(ctx context.Context, root *Board, player CellState) EndgameResult {
+	start := time.Now()
+	if len(GenerateMoves(root, player)) == 0 {
+		// 已是终局：无棋可走，剩余空格归对方（同 solve）
+		margin := root.CountPieces(player) - root.CountPieces(Opponent(player)) - root.CountPieces(Empty)
+		return EndgameResult{Outcome: EndgameOutcome(sign(margin)), Margin: margin, Solved: true, Time: time.Since(start)}
+	}
+	if e.egtt == nil {
+		e.egtt = newTransTable(endgameTTEntries)
+	}
+	e.egtt.newSearch()
+
+	e.stop.Store(false)
+	defer e.stop.Store(false)
+	e.nodes.Store(0)
+	defer context.AfterFunc(ctx, func() { e.stop.Store(true) })()
+
+	res := EndgameResult{}
+	n := len(root.geom.coords)
+	b := cloneBoard(root)
+	// 步数上限逐步放宽，直到上下界重合
+	for limit := max(2*root.CountPieces(Empty), 8); limit <= endgameMaxPly; limit += 4 {
+		lo := &endgameSolver{e: e, root: player, horizon: -n, maxPly: limit}
+		var pv []Move
+		loScore := lo.solve(b, player, 0, -searchInf, searchInf, &pv)
+		if e.stop.Load() {
+			break
+		}
+		hi := &endgameSolver{e: e, root: player, horizon: n, maxPly: limit}
+		hiScore := hi.solve(b, player, 0, loScore-1, searchInf, new([]Move))
+		if e.stop.Load() {
+			break
+		}
+		if loScore == hiScore && len(pv) > 0 {
+			res.Move, res.Margin, res.PV = pv[0], loScore, pv
+			res.Solved, res.OK = true, true
+			break
+		}
+	}
+	res.Nodes, res.Time = e.nodes.Load(), time.Since(start)
+	switch {
+	case !res.Solved:
+	case res.Margin > 0:
+		res.Outcome = EndgameWin
+	case res.Margin < 0:
+		res.Outcome = EndgameLoss
+	}
+	return res
+}
+
+// endgameMaxPly 求解路径长度上限。跳跃不减少空格，感染跳跃来回换子能拖很久，
+// 上限内上下界仍不重合就放弃（Solved=false），交给普通搜索。
+const endgameMaxPly = 64
+
+// endgameOptimisticKey 叶子按 horizon 计时对行棋方有利（乐观界）的节点键扰动，两种界的分数不能混在同一槽里。
+// 按行棋方而不是根方区分：给 A 求的下界和给 B 求的上界在 B 走的节点上含义相同，可以共用
+const endgameOptimisticKey = 0x9e3779b97f4a7c15
+
+// endgameSolver 一次定界求解：走到 maxPly 的叶子不知道真值，按 horizon 计——
+// horizon 取根方最坏（-格子数）得到下界，取最好（+格子数）得到上界，两者相等即为精确解。
+type endgameSolver struct {
+	e       *Engine
+	root    CellState
+	horizon int // 步数上限处 root 视角的分数
+	maxPly  int
+	path    []uint64 // 根到当前节点的局面键，用于判重复
+	repeats int      // 碰到路径重复的次数；子树里碰到过的结果依赖路径，不进表
+}
+
+// horizonFor 不知道真值的叶子在 side 视角下的分数
+func (s *endgameSolver) horizonFor(side CellState) int {
+	if side == s.root {
+		return s.horizon
+	}
+	return -s.horizon
+}
+
+// nodeKey 残局表的节点键：horizon 对行棋方有利时加扰动（见 endgameOptimisticKey）
+func (s *endgameSolver) nodeKey(hash uint64, side CellState) uint64 {
+	if s.horizonFor(side) > 0 {
+		return hash ^ endgameOptimisticKey
+	}
+	return hash
+}
+
+// solve α-β 定界搜索，返回 side 视角的终局子数差（的上界或下界）。不剔除走法、不加罚分，
+// 只把落到同一格的克隆合成一个（结果完全相同）；
+// 路径上重复出现的局面同样不知道真值（规则里没有重复判和），和步数上限一样按 horizon 计，
+// 这样的结果依赖路径，不写进置换表。
+func (s *endgameSolver) solve(b *Board, side CellState, ply, alpha, beta int, pv *[]Move) int {
+	e := s.e
+	if e.stop.Load() {
+		return 0
+	}
+	e.nodes.Add(1)
+
+	opp := Opponent(side)
+	diff := b.CountPieces(side) - b.CountPieces(opp)
+	empties := b.CountPieces(Empty)
+	if empties == 0 {
+		return diff
+	}
+	moves := GenerateMoves(b, side)
+	if len(moves) == 0 {
+		// 轮到 side 却无棋：剩余空格都归上一手的对方
+		return diff - empties
+	}
+
+	hash := b.hash ^ zobristSide[sideIdx(side)]
+	for _, h := range s.path {
+		if h == hash {
+			s.repeats++
+			return s.horizonFor(side)
+		}
+	}
+	if ply >= s.maxPly {
+		return s.horizonFor(side)
+	}
+	key := s.nodeKey(hash, side)
+	// 置换表深度存剩余步数：剩余步数越多界越紧，浅处可直接复用
+	remain := s.maxPly - ply
+	// 根节点不吃表，保证能拿到完整的最佳着
+	if ply > 0 {
+		if hit, val, flag := e.egtt.probe(key, remain); hit {
+			switch flag {
+			case ttExact:
+				return val
+			case ttLower:
+				alpha = max(alpha, val)
+			case ttUpper:
+				beta = min(beta, val)
+			}
+			if alpha >= beta {
+				return val
+			}
+		}
+	}
+	alphaOrig := alpha
+
+	ttMove, hasTT := e.egtt.probeMove(key)
+	moves = dedupClones(moves)
+	orderEndgameMoves(b, side, moves, ttMove, hasTT)
+
+	s.path = append(s.path, hash)
+	defer func() { s.path = s.path[:len(s.path)-1] }()
+	repeats := s.repeats
+
+	best := -searchInf
+	var bestMove Move
+	for _, mv := range moves {
+		undo := mMakeMoveWithUndo(b, mv, side)
+		var line []Move
+		score := -s.solve(b, opp, ply+1, -beta, -alpha, &line)
+		b.UnmakeMove(undo)
+
+		if score > best {
+			best, bestMove = score, mv
+			*pv = append(append((*pv)[:0], mv), line...)
+		}
+		if score > alpha {
+			alpha = score
+			if alpha >= beta {
+				break
+			}
+		}
+	}
+
+	if e.stop.Load() {
+		return 0
+	}
+	var flag ttFlag
+	switch {
+	case best <= alphaOrig:
+		flag = ttUpper
+	case best >= beta:
+		flag = ttLower
+	default:
+		flag = ttExact
+	}
+	if s.repeats == repeats {
+		e.egtt.store(key, remain, best, flag)
+	}
+	e.egtt.storeMove(key, bestMove)
+	return best
+}
+
+// orderEndgameMoves 残局排序：TT 着优先，其余按子数差的即时收益（感染×2，克隆再 +1）从大到小
+func orderEndgameMoves(b *Board, side CellState, moves []Move, ttMove Move, hasTT bool) {
+	keys := make([]int, len(moves))
+	for i, mv := range moves {
+		if hasTT && mv == ttMove {
+			keys[i] = ttMoveKey
+			continue
+		}
+		keys[i] = 2 * previewInfectedCount(b, mv, side)
+		if mv.IsClone() {
+			keys[i]++
+		}
+	}
+	sort.Stable(movesByKey{moves, keys})
+}
+
+// dedupClones 克隆到同一格的结果完全相同（起点都还在），每个落点只留一个
+func dedupClones(moves []Move) []Move {
+	var seen uint64
+	g := geoms[maxBoardRadius]
+	out := moves[:0]
+	for _, mv := range moves {
+		if mv.IsClone() {
+			bit := uint64(1) << uint(g.indexOf(mv.To))
+			if seen&bit != 0 {
+				continue
+			}
+			seen |= bit
+		}
+		out = append(out, mv)
+	}
+	return out
+}
@@ -0,0 +1,163 @@
+package game
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// randomEndgame 半径 radius 的对局随机走到只剩 empties 个空格；对局提前结束返回 nil
+func randomEndgame(r *rand.Rand, radius, empties int) *GameState {
+	gs := NewGameState(radius)
+	for !gs.GameOver && gs.Board.CountPieces(Empty) > empties {
+		moves := GenerateMoves(gs.Board, gs.CurrentPlayer)
+		gs.MakeMove(moves[r.Intn(len(moves))])
+	}
+	if gs.GameOver {
+		return nil
+	}
+	return gs
+}
+
+// bruteEndgame 参考实现：所有合法走法上的 α-β（不用置换表、不合并走法、不设步数上限），
+// 终局规则同 endgameSolver.solve；路径重复按 0 计——求解器只在重复取什么值都不影响结果时才给 Solved，
+// 两边在 Solved 的局面上应一致。
+// 跳跃来回换子时树会爆炸，*budget 个节点用完就放弃（ok=false）
+func bruteEndgame(b *Board, side CellState, alpha, beta int, path map[uint64]bool, budget *int) (int, bool) {
+	if *budget--; *budget < 0 {
+		return 0, false
+	}
+	opp := Opponent(side)
+	diff := b.CountPieces(side) - b.CountPieces(opp)
+	empties := b.CountPieces(Empty)
+	if empties == 0 {
+		return diff, true
+	}
+	moves := GenerateMoves(b, side)
+	if len(moves) == 0 {
+		return diff - empties, true
+	}
+	hash := b.hash ^ zobristSide[sideIdx(side)]
+	if path[hash] {
+		return 0, true
+	}
+	path[hash] = true
+	defer delete(path, hash)
+	orderEndgameMoves(b, side, moves, Move{}, false)
+	best := -searchInf
+	for _, mv := range moves {
+		undo := mMakeMoveWithUndo(b, mv, side)
+		v, ok := bruteEndgame(b, opp, -beta, -alpha, path, budget)
+		b.UnmakeMove(undo)
+		if !ok {
+			return 0, false
+		}
+		best = max(best, -v)
+		alpha = max(alpha, best)
+		if alpha >= beta {
+			break
+		}
+	}
+	return best, true
+}
+
+// TestSolveEndgameMatchesBruteForce 半径 2 随机下到剩 2~3 个空格，双方各求一次，求解器的 Margin 应与暴力 α-β 一致。
+// 所有局面共用一个引擎，残局表跨局面、跨根方保留，表里的界不能串用。暴力搜索在预算内搜不完的局面跳过
+func TestSolveEndgameMatchesBruteForce(t *testing.T) {
+	r := rand.New(rand.NewSource(25)) // 第 19 个局面的最优线里有 0 感染跳跃
+	e := NewEngine(EngineOptions{TTEntries: 1 << 12})
+	checked := 0
+	for i := 0; i < 150; i++ {
+		gs := randomEndgame(r, 2, 2+r.Intn(2))
+		if gs == nil {
+			continue
+		}
+		b := gs.Board
+		for _, side := range []CellState{gs.CurrentPlayer, Opponent(gs.CurrentPlayer)} {
+			budget := 30000
+			want, ok := bruteEndgame(b, side, -searchInf, searchInf, map[uint64]bool{}, &budget)
+			if !ok {
+				continue
+			}
+			er := e.SolveEndgame(context.Background(), b, side)
+			if !er.Solved {
+				continue
+			}
+			if er.Margin != want || er.Outcome != EndgameOutcome(sign(want)) {
+				t.Fatalf("局面 %d（%v 走）: 求解器 %d/%v，暴力 %d", i, side, er.Margin, er.Outcome, want)
+			}
+			if er.OK && !isLegal(b, er.Move, side) {
+				t.Fatalf("局面 %d: 最佳着 %v 不合法", i, er.Move)
+			}
+			checked++
+		}
+	}
+	if checked < 20 {
+		t.Fatalf("只比对了 %d 个局面", checked)
+	}
+}
+
+// TestEndgameNodeKey 残局表跨根方保留：同一节点上含义相同的界共用键，含义相反的不能撞键
+func TestEndgameNodeKey(t *testing.T) {
+	const h = 12345
+	loA := &endgameSolver{root: PlayerA, horizon: -61}
+	hiA := &endgameSolver{root: PlayerA, horizon: 61}
+	loB := &endgameSolver{root: PlayerB, horizon: -61}
+	hiB := &endgameSolver{root: PlayerB, horizon: 61}
+	for _, side := range []CellState{PlayerA, PlayerB} {
+		if loA.nodeKey(h, side) != hiB.nodeKey(h, side) || hiA.nodeKey(h, side) != loB.nodeKey(h, side) {
+			t.Fatalf("%v 走: A 的下界与 B 的上界含义相同，键应相同", side)
+		}
+		if loA.nodeKey(h, side) == loB.nodeKey(h, side) {
+			t.Fatalf("%v 走: A 的下界与 B 的下界含义相反，键不能相同", side)
+		}
+	}
+}
+
+// TestSolveEndgameOpponentStuck 一步把对手封死，剩余空格全归自己
+func TestSolveEndgameOpponentStuck(t *testing.T) {
+	b := NewBoard(2)
+	for _, c := range b.AllCoords() {
+		_ = b.Set(c, PlayerA)
+	}
+	// B 只剩一子，周围一圈都是 A；A 克隆到 (1,0) 就吃掉它
+	_ = b.Set(HexCoord{2, 0}, PlayerB)
+	_ = b.Set(HexCoord{1, 0}, Empty)
+	_ = b.Set(HexCoord{-2, 0}, Empty)
+	_ = b.Set(HexCoord{-2, 1}, Empty)
+
+	er := NewEngine(EngineOptions{}).SolveEndgame(context.Background(), b, PlayerA)
+	if !er.Solved || er.Outcome != EndgameWin {
+		t.Fatalf("A 应必胜: %+v", er)
+	}
+	if er.Margin != len(b.AllCoords()) {
+		t.Fatalf("Margin = %d，期望整盘 %d", er.Margin, len(b.AllCoords()))
+	}
+
+	// 走完这步轮到 B 已无棋可走：直接是终局，结果按规则算而不是和棋
+	mMakeMoveWithUndo(b, er.Move, PlayerA)
+	er = NewEngine(EngineOptions{}).SolveEndgame(context.Background(), b, PlayerB)
+	if !er.Solved || er.OK || er.Outcome != EndgameLoss || er.Margin != -len(b.AllCoords()) {
+		t.Fatalf("B 无棋可走应判负 %d: %+v", -len(b.AllCoords()), er)
+	}
+}
+
+// TestSearchSwitchesToEndgame 空格数进入阈值后 Search 直接返回求解结果
+func TestSearchSwitchesToEndgame(t *testing.T) {
+	r := rand.New(rand.NewSource(9))
+	var gs *GameState
+	for gs == nil {
+		gs = randomEndgame(r, 4, 2)
+	}
+	e := NewEngine(EngineOptions{TTEntries: 1 << 12, EndgameEmpties: 3})
+	res := e.Search(context.Background(), gs.Board, gs.CurrentPlayer, SearchLimits{MaxDepth: 2, MoveTime: 5 * time.Second})
+	if !res.OK || !res.Solved {
+		t.Fatalf("应由残局求解器给出结果: %+v", res)
+	}
+
+	off := NewEngine(EngineOptions{TTEntries: 1 << 12, EndgameEmpties: -1})
+	if res := off.Search(context.Background(), gs.Board, gs.CurrentPlayer, SearchLimits{MaxDepth: 2}); res.Solved {
+		t.Fatal("阈值 <0 时不应进入求解器")
+	}
+}
@@ -0,0 +1,191 @@
+// internal/game/engine.go
+package game
+
+import (
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// EngineOptions 搜索引擎的可调项；零值即默认配置
+type EngineOptions struct {
+	// TTMB 置换表占的内存（MB），按桶数向下取整到 2 的幂；<=0 且没给 TTEntries 时用 defaultTTMB。
+	// GUI 里几十 MB 就够，批量自对弈每个引擎各占一份，按机器内存给
+	TTMB      int
+	TTEntries int // 直接给槽位数（测试用），TTMB 为 0 时才看它
+	// Evaluator 叶子评估器，见 NewEvaluator；nil 用静态评估。
+	// NNUE 这类能增量更新的评估器在搜索树里随走子更新累加器
+	Evaluator Evaluator
+	Eval      EvalFunc // 直接给评估函数（测试用），非 nil 时优先于 Evaluator
+
+	EndgameEmpties int // 空格数 ≤ 它时改用残局精确求解；0 用 defaultEndgameEmpties，<0 关闭
+	// SymmetryEmpties 空格数 ≥ 它时置换表按对称规范局面取键，开局不同朝向的同一局面共享结果；
+	// 0 用 defaultSymmetryEmpties，<0 关闭
+	SymmetryEmpties int
+
+	// QuiesceFlips 名义深度用完后继续搜感染 ≥ 它的走法（静态搜索）；0 用 defaultQuiesceFlips，<0 关闭
+	QuiesceFlips int
+	// QuiesceDelta 静态搜索 delta 剪枝里每感染一子的最大涨分，按评估器的刻度给；
+	// 0 时静态评估用 quiesceFlipGain、学出来的评估不剪，<0 不剪
+	QuiesceDelta int
+
+	Threads int // Lazy SMP 线程数（含主线程），Analyze 也按它分摊根走法；共用一张置换表；<=0 用 GOMAXPROCS
+
+	// Book 开局库，非 nil 时搜索前先查库（此时不再强制开局只走外圈克隆）
+	Book        *Book
+	BookOptions BookOptions
+	BookSeed    int64 // 查库加权随机的种子；0 用当前时间
+}
+
+// SearchStats 一次搜索的统计信息
+type SearchStats struct {
+	Nodes    uint64  // α-β 节点数
+	TTProbes uint64  // 置换表 probe 次数
+	TTHits   uint64  // 命中次数
+	HitRate  float64 // 命中率（百分比）
+}
+
+// Engine 是一个独立的 α-β 搜索实例：自带置换表与统计，
+// 多个引擎（自对弈双方、锦标赛各选手）可在同一进程里并发使用，互不干扰。
+// 搜索入口内部自己开 Lazy SMP 线程；同一个 Engine 不支持被多个 goroutine 同时调用搜索入口。
+type Engine struct {
+	opts EngineOptions
+	tt   *transTable
+	egtt *transTable // 残局求解专用，首次求解时才分配
+	eval EvalFunc
+	inc  incrementalEvaluator // 评估器能增量更新时非 nil
+	// learned 评估是学出来的（CNN/NNUE/线性），不再叠加跳跃罚分这类手调启发
+	learned bool
+	stop    atomic.Bool   // 当前搜索被取消/超时，α-β 见到后立即返回
+	nodes   atomic.Uint64 // 本次搜索访问的 α-β 节点数
+	bookRng *rand.Rand    // 开局库挑着用，只在搜索入口里用，不必加锁
+	ttCache *TTCache      // LoadTTCache 收下的分析缓存，ClearTT、ResizeTT 后重灌
+}
+
+// NewEngine 按 opts 创建引擎并分配置换表
+func NewEngine(opts EngineOptions) *Engine {
+	e := &Engine{
+		opts: opts,
+		tt:   newTransTable(opts.ttSlots()),
+		eval: opts.Eval,
+	}
+	if opts.Book != nil {
+		seed := opts.BookSeed
+		if seed == 0 {
+			seed = time.Now().UnixNano()
+		}
+		e.bookRng = rand.New(rand.NewSource(seed))
+	}
+	if e.eval == nil {
+		ev := opts.Evaluator
+		if ev == nil {
+			ev = staticEvaluator{}
+		}
+		e.eval = ev.Evaluate
+		e.inc, _ = ev.(incrementalEvaluator)
+		if l, ok := ev.(learnedEvaluator); ok {
+			e.learned = l.learned()
+		}
+	}
+	return e
+}
+
+// ttSlots 按 TTMB / TTEntries 算出置换表槽位数
+func (o EngineOptions) ttSlots() int {
+	if o.TTMB <= 0 && o.TTEntries > 0 {
+		return o.TTEntries
+	}
+	return ttSlotsForMB(o.TTMB)
+}
+
+// ClearTT 清空置换表（含残局表）并重新灌入 LoadTTCache 给的缓存，换新对局后调用；不能与搜索并发
+func (e *Engine) ClearTT() {
+	e.tt.clear()
+	if e.egtt != nil {
+		e.egtt.clear()
+	}
+	e.preloadTTCache()
+}
+
+// ResizeTT 把置换表改成 mb 兆字节并清空（同样重灌缓存）；不能与搜索并发
+func (e *Engine) ResizeTT(mb int) {
+	e.opts.TTMB = mb
+	e.tt.resize(ttSlotsForMB(mb))
+	e.preloadTTCache()
+}
+
+// TTSizeMB 置换表实际占的内存（MB）
+func (e *Engine) TTSizeMB() int {
+	return len(e.tt.entries) * ttSlotBytes >> 20
+}
+
+// probeBook 在开局库里按 BookOptions 挑一步；没配库或库里没有时 ok=false
+func (e *Engine) probeBook(b *Board, player CellState) (Move, bool) {
+	if e.opts.Book == nil {
+		return Move{}, false
+	}
+	return e.opts.Book.Pick(b, player, e.opts.BookOptions, e.bookRng)
+}
+
+// Options 返回创建引擎时的配置
+func (e *Engine) Options() EngineOptions {
+	return e.opts
+}
+
+// EvalWeights 引擎用静态评估时返回它的权重（没给权重文件就是默认权重）；
+// 用学出来的评估器或直接给了 Eval 时 ok=false
+func (e *Engine) EvalWeights() (w EvalWeights, ok bool) {
+	if e.opts.Eval != nil {
+		return EvalWeights{}, false
+	}
+	switch ev := e.opts.Evaluator.(type) {
+	case nil:
+		return defaultEvalWeights, true
+	case staticEvaluator:
+		if ev.w == nil {
+			return defaultEvalWeights, true
+		}
+		return *ev.w, true
+	}
+	return EvalWeights{}, false
+}
+
+// Stats 返回最近一次搜索的置换表统计
+func (e *Engine) Stats() SearchStats {
+	probes, hits, rate := e.tt.stats()
+	return SearchStats{Nodes: e.nodes.Load(), TTProbes: probes, TTHits: hits, HitRate: rate}
+}
+
+var (
+	defaultEngineOnce sync.Once
+	defaultEngine     *Engine
+)
+
+// DefaultEngine 返回包级共享引擎，供旧的包级函数使用；首次调用时才分配置换表。
+func DefaultEngine() *Engine {
+	defaultEngineOnce.Do(func() {
+		defaultEngine = NewEngine(EngineOptions{})
+	})
+	return defaultEngine
+}
+
+// FindBestMoveAtDepth 用默认引擎搜索，保留给旧调用方
+func FindBestMoveAtDepth(b *Board, player CellState, depth int) (Move, bool) {
+	return DefaultEngine().FindBestMoveAtDepth(b, player, depth)
+}
+
+// IterativeDeepening 用默认引擎迭代加深，保留给旧调用方
+func IterativeDeepening(root *Board, player CellState, maxDepth int) (best Move, bestScore int, ok bool) {
+	return DefaultEngine().IterativeDeepening(root, player, maxDepth)
+}
+
+// DeepSearch 用默认引擎做一次固定深度 α-β
+func DeepSearch(b *Board, hash uint64, side CellState, depth int) int {
+	return DefaultEngine().DeepSearch(b, hash, side, depth)
+}
+
+// AlphaBeta 用默认引擎从 player 的视角评估“对手先走”的局面
+func AlphaBeta(b *Board, player CellState, depth int) int {
+	return DefaultEngine().AlphaBeta(b, player, depth)
+}
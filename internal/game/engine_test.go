@@ -0,0 +1,86 @@
+package game
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+// TestEnginesIndependent 两个引擎并发搜索（配合 -race），且统计各自独立
+func TestEnginesIndependent(t *testing.T) {
+	ea := NewEngine(EngineOptions{TTEntries: 1 << 12})
+	eb := NewEngine(EngineOptions{TTEntries: 1 << 12})
+
+	var wg sync.WaitGroup
+	for _, e := range []*Engine{ea, eb} {
+		wg.Add(1)
+		go func(e *Engine) {
+			defer wg.Done()
+			gs := NewGameState(4)
+			for i := 0; i < 4 && !gs.GameOver; i++ {
+				mv, ok := e.FindBestMoveAtDepth(gs.Board, gs.CurrentPlayer, 2)
+				if !ok {
+					t.Error("没有找到走法")
+					return
+				}
+				gs.MakeMove(mv)
+			}
+		}(e)
+	}
+	wg.Wait()
+
+	if len(ea.tt.entries) != 1<<12 || ea.tt == eb.tt {
+		t.Fatal("引擎应各自持有指定大小的置换表")
+	}
+	eb.AlphaBeta(NewGameState(4).Board, PlayerA, 2)
+	ea.tt.resetStats()
+	if s := eb.Stats(); s.TTProbes == 0 {
+		t.Fatal("重置 ea 不应影响 eb 的统计")
+	}
+}
+
+// TestTTSharedAcrossSides 静态评估不对称（边缘分只算一方）：替 A 搜过留在表里的条目，
+// 换成替 B 搜时含义也得一样，分数应与新引擎相同
+func TestTTSharedAcrossSides(t *testing.T) {
+	r := rand.New(rand.NewSource(7))
+	gs := NewGameState(4)
+	for i := 0; i < 12; i++ {
+		moves := GenerateMoves(gs.Board, gs.CurrentPlayer)
+		gs.MakeMove(moves[r.Intn(len(moves))])
+	}
+	b, side := gs.Board, gs.CurrentPlayer
+	opts := EngineOptions{TTEntries: 1 << 16, QuiesceFlips: -1}
+	e := NewEngine(opts)
+	e.DeepSearch(b, 0, side, 2)
+	for _, mv := range GenerateMoves(b, side) {
+		undo := mMakeMoveWithUndo(b, mv, side)
+		got := e.DeepSearch(b, 0, Opponent(side), 1)
+		want := NewEngine(opts).DeepSearch(b, 0, Opponent(side), 1)
+		b.UnmakeMove(undo)
+		if got != want {
+			t.Fatalf("%v 之后: 复用表 %d，新引擎 %d", mv, got, want)
+		}
+	}
+}
+
+// TestLazySMP 多线程共享置换表（配合 -race），主线程给出的着与主变例都合法
+func TestLazySMP(t *testing.T) {
+	e := NewEngine(EngineOptions{TTEntries: 1 << 16, Threads: 4})
+	gs := NewGameState(4)
+	for i := 0; i < 6 && !gs.GameOver; i++ {
+		res := e.Search(context.Background(), gs.Board, gs.CurrentPlayer, SearchLimits{MaxDepth: 3})
+		if !res.OK || !isLegal(gs.Board, res.Move, gs.CurrentPlayer) {
+			t.Fatalf("第 %d 步给出不合法的着 %+v", i, res)
+		}
+		b, side := cloneBoard(gs.Board), gs.CurrentPlayer
+		for _, mv := range res.PV {
+			if !isLegal(b, mv, side) {
+				t.Fatalf("第 %d 步主变例 %v 里 %v 不合法", i, res.PV, mv)
+			}
+			mMakeMoveWithUndo(b, mv, side)
+			side = Opponent(side)
+		}
+		gs.MakeMove(res.Move)
+	}
+}
@@ -0,0 +1,216 @@
+// internal/game/eval_linear.go
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// 线性评估：train1.py 在 features_exporter 导出的 11 维特征上拟合胜负（±1），
+// 这里按同样的定义在 Board 上算特征，分数 = (w·x + b) × valueScoreScale。
+// 特征的定义（连同其中的怪癖）必须和 features_exporter/extractFeatures 保持一致，否则权重对不上。
+const LinearFeatureLen = 11
+
+// 导出器里写死的阶段阈值与罚分，特征定义的一部分，不要单独改
+const (
+	openingPhaseThresh    = 0.82 // 空位比例 ≥ 它算开局
+	openingPenaltyWeight  = 10   // 开局子数落后每子
+	midgamePhaseThresh    = 0.6  // 空位比例 < 它才算对手上一步周边的空格
+	midgameLastMoveWeight = 15   // 上一步落点周边每个空格
+)
+
+// LinearFeatureNames 特征顺序，也是权重文件里 features 字段的内容
+var LinearFeatureNames = [LinearFeatureLen]string{
+	"empty_ratio", "piece_diff", "outer_diff", "danger", "mobility",
+	"inf_diff", "holes", "opening_penalty", "early_jump", "last_move_bonus", "last_is_jump",
+}
+
+// LinearEvaluator 线性模型评估器
+type LinearEvaluator struct {
+	Weights [LinearFeatureLen]float64
+	Bias    float64
+	name    string
+}
+
+// linearFile train1.py 写出的权重文件
+type linearFile struct {
+	Features []string  `json:"features"`
+	Weights  []float64 `json:"weights"`
+	Bias     float64   `json:"bias"`
+}
+
+// LoadLinearEvaluator 读 train1.py 写出的 JSON 权重
+func LoadLinearEvaluator(path string) (*LinearEvaluator, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f linearFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(f.Weights) != LinearFeatureLen {
+		return nil, fmt.Errorf("%s: %d 个权重，需要 %d", path, len(f.Weights), LinearFeatureLen)
+	}
+	if f.Features != nil {
+		for i, n := range f.Features {
+			if i >= LinearFeatureLen || n != LinearFeatureNames[i] {
+				return nil, fmt.Errorf("%s: 特征 %v 与程序的 %v 不一致", path, f.Features, LinearFeatureNames)
+			}
+		}
+	}
+	e := &LinearEvaluator{Bias: f.Bias, name: "linear:" + path}
+	copy(e.Weights[:], f.Weights)
+	return e, nil
+}
+
+func (e *LinearEvaluator) Name() string {
+	if e.name == "" {
+		return "linear"
+	}
+	return e.name
+}
+
+func (e *LinearEvaluator) learned() bool { return true }
+
+func (e *LinearEvaluator) Evaluate(b *Board, player CellState) int {
+	x := LinearFeatures(b, player)
+	v := e.Bias
+	for i, w := range e.Weights {
+		v += w * x[i]
+	}
+	return int(v * valueScoreScale)
+}
+
+// LinearFeatures player 视角的 11 维特征，顺序见 LinearFeatureNames
+func LinearFeatures(b *Board, player CellState) [LinearFeatureLen]float64 {
+	op := Opponent(player)
+	var mine, theirs, empties []HexCoord
+	for _, c := range b.AllCoords() {
+		switch b.Get(c) {
+		case player:
+			mine = append(mine, c)
+		case op:
+			theirs = append(theirs, c)
+		case Empty:
+			empties = append(empties, c)
+		}
+	}
+	rEmp := float64(len(empties)) / float64(len(b.AllCoords()))
+
+	// 外圈差、暴露数。暴露沿用导出器的判定：相邻有对手，或邻格再走 (1,-1)/(-1,1) 有对手
+	outer, danger := 0, 0
+	for _, c := range mine {
+		if isOuter(c, b.radius) {
+			outer++
+		}
+		if linearInRange(b, c, op) {
+			danger++
+		}
+	}
+	for _, c := range theirs {
+		if isOuter(c, b.radius) {
+			outer--
+		}
+	}
+
+	// 机动性：我方子到空格的 (子, 空格) 对，距离 1 算克隆、距离 2 算跳跃
+	cloneMob, fullMob := 0, 0
+	for _, f := range mine {
+		for _, t := range empties {
+			switch HexDist(f, t) {
+			case 1:
+				cloneMob++
+			case 2:
+				fullMob++
+			}
+		}
+	}
+	mobDiff := fullMob - cloneMob
+	if rEmp >= openingPhaseThresh {
+		mobDiff = cloneMob
+	}
+
+	// 加权感染差。导出器不限距离：任何子到任何空格都算，距离 2 权重 1，其余权重 2
+	bestInf := func(from []HexCoord, pl CellState) int {
+		best := 0
+		for _, t := range empties {
+			cnt := 0
+			for _, d := range Directions {
+				if b.Get(t.Add(d)) == Opponent(pl) {
+					cnt++
+				}
+			}
+			if cnt == 0 {
+				continue
+			}
+			for _, f := range from {
+				w := 2
+				if HexDist(f, t) == 2 {
+					w = 1
+				}
+				best = max(best, cnt*w)
+				if best >= 2*cnt {
+					break
+				}
+			}
+		}
+		return best
+	}
+	infDiff := bestInf(mine, player) - bestInf(theirs, op)
+
+	openingPenalty := 0
+	if rEmp >= openingPhaseThresh && len(theirs) > len(mine) {
+		openingPenalty = (len(theirs) - len(mine)) * openingPenaltyWeight
+	}
+	earlyJump := 0
+	if rEmp >= openingPhaseThresh && cloneMob > 0 && infDiff == 0 {
+		earlyJump = 20
+	}
+
+	lastBonus, lastJump := 0, 0.0
+	if lm := b.LastMove; lm != (Move{}) {
+		if lm.IsJump() {
+			lastJump = 1
+		}
+		if rEmp < midgamePhaseThresh {
+			// 导出器的 get 对棋盘外返回 0（空），边上的落点棋盘外的邻格也算空格
+			for _, d := range Directions {
+				if nb := lm.To.Add(d); !b.InBounds(nb) || b.Get(nb) == Empty {
+					lastBonus += midgameLastMoveWeight
+				}
+			}
+		}
+	}
+
+	return [LinearFeatureLen]float64{
+		rEmp,
+		float64(len(mine) - len(theirs)),
+		float64(outer),
+		float64(danger),
+		float64(mobDiff),
+		float64(infDiff),
+		float64(evaluateHoles(b, player)),
+		float64(openingPenalty),
+		float64(earlyJump),
+		float64(lastBonus),
+		lastJump,
+	}
+}
+
+// linearInRange 导出器里的 inOppRange，原样照搬
+func linearInRange(b *Board, c HexCoord, op CellState) bool {
+	for _, d := range Directions {
+		nb := c.Add(d)
+		if b.Get(nb) == op {
+			return true
+		}
+		for _, d2 := range Directions {
+			if abs(d2.Q)+abs(d2.R) == 2 && b.Get(nb.Add(d2)) == op {
+				return true
+			}
+		}
+	}
+	return false
+}
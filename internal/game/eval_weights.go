@@ -0,0 +1,77 @@
+// internal/game/eval_weights.go
+package game
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// EvalWeights 启发式评估（evaluateStatic）的可调权重。
+// 权重文件是 JSON，键见字段 tag；文件里没写的键保持默认值，写错的键报错。
+type EvalWeights struct {
+	Piece     int     `json:"piece"`      // 敌我棋子差，每子
+	Edge      int     `json:"edge"`       // 我方外圈每子
+	BlockDiff int     `json:"block_diff"` // 3+ 连通块数量差，每块
+	BlockFill float64 `json:"block_fill"` // 填充率低于它时才算连通块
+	CloneInf  int     `json:"clone_inf"`  // 下一手克隆最大感染数
+	JumpInf   int     `json:"jump_inf"`   // 下一手跳越最大感染数
+	WeakJump  int     `json:"weak_jump"`  // 上一步是落点旁最多 1 个己方子的“弱跳越”，罚跳的一方
+}
+
+// DefaultEvalWeights 当前手调的默认值
+func DefaultEvalWeights() EvalWeights {
+	return EvalWeights{
+		Piece:     5,
+		Edge:      2,
+		BlockDiff: 4,
+		BlockFill: 0.4,
+		CloneInf:  3,
+		JumpInf:   2,
+		WeakJump:  50,
+	}
+}
+
+var defaultEvalWeights = DefaultEvalWeights()
+
+// LoadEvalWeights 读权重文件，缺的键用默认值
+func LoadEvalWeights(path string) (EvalWeights, error) {
+	w := DefaultEvalWeights()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return w, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&w); err != nil {
+		return DefaultEvalWeights(), fmt.Errorf("%s: %w", path, err)
+	}
+	return w, nil
+}
+
+// Save 写成带缩进的 JSON，LoadEvalWeights 能原样读回
+func (w EvalWeights) Save(path string) error {
+	data, err := json.MarshalIndent(w, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// WeightsEvaluator 用给定权重的启发式评估器
+func WeightsEvaluator(w EvalWeights) Evaluator {
+	return staticEvaluator{w: &w}
+}
+
+// WithWeights 给评估器描述补上权重文件（命令行 -weights 用）：空或 static 变成 static:path，
+// 其它评估器不吃这个文件，报错；path 为空原样返回
+func WithWeights(spec, path string) (string, error) {
+	if path == "" {
+		return spec, nil
+	}
+	if spec != "" && spec != "static" {
+		return "", fmt.Errorf("权重文件只对 static 评估器有效，当前评估器 %q", spec)
+	}
+	return "static:" + path, nil
+}
@@ -1,46 +1,10 @@
 // file: internal/game/evaluate.go
 package game
 
-// 可调参数
-var (
-	cloneThresh = 0.25      // 克隆/跳跃阈值
-	jumpThresh  = 1.0 / 3.0 // 跳跃/残局阈值
-	dangerW     = 40        // 暴露惩罚权重
-)
-
-// 加分上下限
-const (
-	CLONE_BONUS_MAX       = 30
-	CLONE_BONUS_MIN       = 14
-	JUMP_BONUS_MAX        = 3
-	JUMP_BONUS_MIN        = 0
-	blockW                = 2
-	zeroInfectJumpPenalty = -5000
-)
-
-// ========== 新增部分：加权感染数与开局惩罚常量 ==========
-const (
-	// 开局阶段阈值：当空位比例 r ≥ 0.82 时，视为“开局”
-	openingPhaseThresh = 0.82
-	// 开局被对手感染时的额外惩罚权重
-	openingPenaltyWeight = 10
-
-	// 跳跃感染权重（跳跃感染得分较低）
-	jumpInfWeight = 1
-	// 克隆感染权重（克隆感染得分较高）
-	cloneInfWeight = 2
-
-	// ========= 新增 =========
-	// 如果处于开局阶段(r ≥ openingPhaseThresh)，且我方存在可用克隆却没有使用克隆，
-	// 那么静态评估扣分（值可以根据调试再微调）
-	earlyJumpPenalty = -50
-)
-
-const (
-	// 中期阶段阈值：当空位比例 r < 0.5 时视为“中期”
-	midgamePhaseThresh = 0.6
-	// 中期阶段，对手上一步周边位置权重
-	midgameLastMoveWeight = 15
+import (
+	"fmt"
+	"math/bits"
+	"strings"
 )
 
 // HexCoord.Add：方便邻格计算
@@ -48,61 +12,6 @@ func (h HexCoord) Add(o HexCoord) HexCoord {
 	return HexCoord{h.Q + o.Q, h.R + o.R}
 }
 
-// dynamicPieceW：原动态克隆权重逻辑
-func dynamicPieceW(r float64) int {
-	if r >= cloneThresh {
-		t := (r - cloneThresh) / (1.0 - cloneThresh)
-		return int(t*float64(CLONE_BONUS_MAX-CLONE_BONUS_MIN) + float64(CLONE_BONUS_MIN) + 0.5)
-	}
-	return CLONE_BONUS_MIN
-}
-
-// dynamicJumpW：原动态跳跃权重逻辑
-func dynamicJumpW(r float64) int {
-	if r <= jumpThresh {
-		return JUMP_BONUS_MAX
-	} else if r < 1.0 {
-		t := (1.0 - r) / (1.0 - jumpThresh)
-		return int(t*float64(JUMP_BONUS_MAX-JUMP_BONUS_MIN) + float64(JUMP_BONUS_MIN) + 0.5)
-	}
-	return JUMP_BONUS_MIN
-}
-
-// dynamicInfW：随空位比例 r，让感染分前期小、后期大
-func dynamicInfW(r float64) int {
-	const minW, maxW = 1, 4
-	t := 1.0 - r
-	return int(t*float64(maxW-minW) + float64(minW) + 0.5)
-}
-
-// maxInf：计算某方最大单步感染数
-func maxInf(b *Board, pl CellState) int {
-	best := 0
-	for _, m := range GenerateMoves(b, pl) {
-		if cnt, _ := m.ApplyPreview(b, pl); cnt > best {
-			best = cnt
-		}
-	}
-	return best
-}
-
-// isInOpponentRange：判断 c 是否在对手一步可达范围内
-func isInOpponentRange(b *Board, c HexCoord, opponent CellState) bool {
-	for _, dir := range Directions {
-		nb := c.Add(dir)
-		if b.Get(nb) == opponent {
-			return true
-		}
-		for _, dir2 := range Directions {
-			nb2 := nb.Add(dir2)
-			if HexDist(nb2, c) == 2 && b.Get(nb2) == opponent {
-				return true
-			}
-		}
-	}
-	return false
-}
-
 // ApplyPreview：在不修改棋盘的情况下预览感染数
 func (m Move) ApplyPreview(b *Board, player CellState) (infected int, ok bool) {
 	coords, undo := m.MakeMove(b, player)
@@ -115,106 +24,129 @@ func Evaluate(b *Board, player CellState) int {
 	return evaluateStatic(b, player)
 }
 
-func dynamicEdgeW(r float64) int {
-	// r = 空格占比，开局 r≈1，残局 r≈0
-	if r > 0.6 {
-		return 6 // 开局大力冲边
-	} else if r > 0.3 {
-		return 3
-	}
-	return 1 // 残局趋于中性
-}
-
 func isOuter(c HexCoord, radius int) bool {
 	ring := max3(abs(c.Q), abs(c.R), abs(c.Q+c.R))
 	return ring == radius // 最外一圈
 }
 
-func outerRingCoords(b *Board) []HexCoord {
-	var ring []HexCoord
-	for _, c := range b.AllCoords() {
-		if isOuter(c, b.radius) && b.Get(c) != Blocked {
-			ring = append(ring, c)
-		}
+// evaluateStatic 用默认权重的启发式评估
+func evaluateStatic(b *Board, player CellState) int {
+	return defaultEvalWeights.evaluate(b, player)
+}
+
+// EvalFeatures evaluateStatic 各项的原始特征（player 视角），分数 = Σ 权重 × 特征，见 EvalWeights.Score
+type EvalFeatures struct {
+	PieceDiff int     // 敌我棋子差
+	Edge      int     // 我方外圈子数
+	BlockDiff int     // 3+ 连通块数量差（填充率 ≥ BlockFill 时不计分）
+	FillRatio float64 // 非空格占比
+	WeakJump  int     // 上一步是弱跳越：对方跳的 +1，我方跳的 -1
+	CloneInf  int     // 下一手克隆最大感染数，我方 - 对方
+	JumpInf   int     // 下一手跳越最大感染数，我方 - 对方
+}
+
+// evaluate 启发式评估，各项权重见 EvalWeights
+func (w *EvalWeights) evaluate(b *Board, player CellState) int {
+	return w.Score(StaticFeatures(b, player))
+}
+
+// Score 按权重把特征加成分数
+func (w *EvalWeights) Score(f EvalFeatures) int {
+	s := f.PieceDiff*w.Piece +
+		f.Edge*w.Edge +
+		f.WeakJump*w.WeakJump +
+		f.CloneInf*w.CloneInf +
+		f.JumpInf*w.JumpInf
+	if f.FillRatio < w.BlockFill {
+		s += f.BlockDiff * w.BlockDiff
 	}
-	return ring
+	return s
 }
 
-func compSizeAt(b *Board, start HexCoord, color CellState) int {
-	if b.Get(start) != color {
-		return 0
+// EvalTerm 评估里的一项：原始特征值 × 权重 = 贡献
+type EvalTerm struct {
+	Name         string // 与权重文件里的键同名
+	Value        float64
+	Weight       float64
+	Contribution int
+	Note         string // 这一项为什么没计分之类的说明，可为空
+}
+
+// EvalExplanation 启发式评估的逐项拆解，各项贡献之和即 Total
+type EvalExplanation struct {
+	Player CellState
+	Terms  []EvalTerm
+	Total  int
+}
+
+// ExplainEval 用默认权重拆解 evaluateStatic(b, player)
+func ExplainEval(b *Board, player CellState) EvalExplanation {
+	return defaultEvalWeights.Explain(b, player)
+}
+
+// Explain 拆解 w 下的启发式评估，Total 与 Score 的结果一致
+func (w *EvalWeights) Explain(b *Board, player CellState) EvalExplanation {
+	f := StaticFeatures(b, player)
+	term := func(name string, v, weight int) EvalTerm {
+		return EvalTerm{Name: name, Value: float64(v), Weight: float64(weight), Contribution: v * weight}
 	}
-	visited := make(map[HexCoord]bool, 16)
-	stack := []HexCoord{start}
-	visited[start] = true
-	size := 0
-	for len(stack) > 0 {
-		cur := stack[len(stack)-1]
-		stack = stack[:len(stack)-1]
-		size++
-		for _, d := range cloneDirs {
-			nb := HexCoord{cur.Q + d.Q, cur.R + d.R}
-			if !visited[nb] && b.Get(nb) == color {
-				visited[nb] = true
-				stack = append(stack, nb)
-			}
+	block := term("block_diff", f.BlockDiff, w.BlockDiff)
+	if f.FillRatio >= w.BlockFill {
+		block.Contribution = 0
+		block.Note = fmt.Sprintf("fill %.2f >= %.2f, off", f.FillRatio, w.BlockFill)
+	}
+	ex := EvalExplanation{
+		Player: player,
+		Terms: []EvalTerm{
+			term("piece", f.PieceDiff, w.Piece),
+			term("edge", f.Edge, w.Edge),
+			block,
+			term("weak_jump", f.WeakJump, w.WeakJump),
+			term("clone_inf", f.CloneInf, w.CloneInf),
+			term("jump_inf", f.JumpInf, w.JumpInf),
+		},
+	}
+	for _, t := range ex.Terms {
+		ex.Total += t.Contribution
+	}
+	return ex
+}
+
+// String 每项一行的表格，只用 ASCII（UI 的位图字体没有中文）
+func (ex EvalExplanation) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%-10s %6s %6s %6s\n", "term", "value", "weight", "score")
+	for _, t := range ex.Terms {
+		fmt.Fprintf(&sb, "%-10s %6g %6g %6d", t.Name, t.Value, t.Weight, t.Contribution)
+		if t.Note != "" {
+			sb.WriteString("  (" + t.Note + ")")
 		}
+		sb.WriteByte('\n')
 	}
-	return size
+	fmt.Fprintf(&sb, "%-10s %20d\n", "total", ex.Total)
+	return sb.String()
 }
 
-func evaluateStatic(b *Board, player CellState) int {
+// StaticFeatures 算出启发式评估用到的各项特征
+func StaticFeatures(b *Board, player CellState) EvalFeatures {
 	op := Opponent(player)
-
-	// —— 可调权重 —— //
-	const (
-		pieceW          = 5  // 1) 敌我棋子差
-		edgeW           = 2  // 2) 我方外圈每子少量加分
-		blockDiffW      = 4  // 3) 3+ 连通块数量差（<50% 填充才生效）
-		cloneInfW       = 3  // 5) 克隆感染权重（> 跳越）
-		jumpInfW        = 2  // 5) 跳越感染权重
-		weakJumpPenalty = 50 // 4) 弱跳越重罚（可调 120~200）
-	)
+	var f EvalFeatures
 
 	// —— 基础统计 —— //
-	coords := b.AllCoords()
-	total := len(coords)
-	empties := 0
-	myCnt, opCnt := 0, 0
-	for _, c := range coords {
-		switch b.Get(c) {
-		case Empty:
-			empties++
-		case player:
-			myCnt++
-		case op:
-			opCnt++
-		}
-	}
-	filledRatio := float64(total-empties) / float64(total)
+	total := len(b.AllCoords())
+	empties := b.CountPieces(Empty)
+	f.FillRatio = float64(total-empties) / float64(total)
 
 	// 1) 敌我棋子差
-	pieceScore := (myCnt - opCnt) * pieceW
+	f.PieceDiff = b.CountPieces(player) - b.CountPieces(op)
 
-	// 2) 我方外圈少量加分
-	myEdge := 0
-	for _, c := range coords {
-		if b.Get(c) == player && isOuter(c, b.radius) {
-			myEdge++
-		}
-	}
-	edgeScore := myEdge * edgeW
+	// 2) 我方外圈子数
+	f.Edge = bits.OnesCount64(b.mask(player) & b.geom.outer)
 
-	// 3) 3+ 连通块数量差（<50% 才生效）
-	blockScore := 0
-	if filledRatio < 0.4 {
-		myBlocks := countBlocks(b, player)
-		opBlocks := countBlocks(b, op)
-		blockScore = (myBlocks - opBlocks) * blockDiffW
-	}
+	// 3) 3+ 连通块数量差，是否计分由权重里的填充率阈值决定
+	f.BlockDiff = countBlocks(b, player) - countBlocks(b, op)
 
-	// 4) 弱跳越重罚（按你的新规则）
-	weakJumpScore := 0
+	// 4) 弱跳越：跳完落点旁最多 1 个己方子
 	if b.LastMove.IsJump() {
 		mover := b.Get(b.LastMove.To) // 刚跳的那一方，跳后 To 颜色就是 mover
 		if mover == PlayerA || mover == PlayerB {
@@ -225,114 +157,46 @@ func evaluateStatic(b *Board, player CellState) int {
 					sameAdj++
 				}
 			}
-			//fmt.Printf("LM=%v  isJump=%v  sameAdj=%d  mover=%v\n",
-			//	b.LastMove, b.LastMove.IsJump(), sameAdj, mover)
-
 			if sameAdj <= 1 {
 				if mover == player {
-					weakJumpScore -= weakJumpPenalty
+					f.WeakJump = -1
 				} else {
-					weakJumpScore += weakJumpPenalty
+					f.WeakJump = 1
 				}
 			}
 		}
 	}
 
-	// 5) 感染潜力（克隆加分 > 跳越）
-	//    用“下一手最大即刻感染数”的差来刻画潜力，并对克隆/跳跃分别加权
+	// 5) 感染潜力：用“下一手最大即刻感染数”的差来刻画，克隆/跳越分开加权
 	maxCloneJump := func(side CellState) (cloneMax, jumpMax int) {
 		for _, m := range GenerateMoves(b, side) {
 			cnt := previewInfectedCount(b, m, side)
 			if m.IsClone() {
-				if cnt > cloneMax {
-					cloneMax = cnt
-				}
-			} else { // Jump
-				if cnt > jumpMax {
-					jumpMax = cnt
-				}
+				cloneMax = max(cloneMax, cnt)
+			} else {
+				jumpMax = max(jumpMax, cnt)
 			}
 		}
 		return
 	}
 	myCloneMax, myJumpMax := maxCloneJump(player)
 	opCloneMax, opJumpMax := maxCloneJump(op)
-	infPotentialScore := (myCloneMax*cloneInfW + myJumpMax*jumpInfW) -
-		(opCloneMax*cloneInfW + opJumpMax*jumpInfW)
-
-	// —— 汇总 —— //
-	final := pieceScore +
-		edgeScore +
-		blockScore +
-		weakJumpScore +
-		infPotentialScore
-
-	return final
-}
-
-// ─────────────────────────────────────────────────────────────────────────────
-// 辅助：统计“跳跃”走法能够感染的最大棋子数
-// ─────────────────────────────────────────────────────────────────────────────
-func maxJumpInfFromMoves(b *Board, player CellState, moves []Move) int {
-	op := Opponent(player)
-	maxInf := 0
-
-	for _, m := range moves {
-		if !m.IsJump() {
-			continue
-		}
-
-		// 估算：跳到 m.To 后，m.To 周围 6 个方向属于对手的棋子都会被感染
-		cnt := 0
-		for _, dir := range Directions {
-			nb := m.To.Add(dir)
-			if b.Get(nb) == op {
-				cnt++
-			}
-		}
-		if cnt > maxInf {
-			maxInf = cnt
-		}
-	}
-	return maxInf
-}
-
-func maxInfFromMoves(b *Board, pl CellState, moves []Move) int {
-	best := 0
-	for _, m := range moves {
-		cnt := previewInfectedCount(b, m, pl)
-		if cnt > best {
-			best = cnt
-		}
-	}
-	return best
+	f.CloneInf = myCloneMax - opCloneMax
+	f.JumpInf = myJumpMax - opJumpMax
+	return f
 }
 
 // 统计连通块数：每个连通块只要 size>=3 就计 +1
 func countBlocks(b *Board, player CellState) int {
-	visited := make(map[HexCoord]bool)
 	blocks := 0
-	for _, c := range b.AllCoords() {
-		if visited[c] || b.Get(c) != player {
-			continue
-		}
+	rest := b.mask(player)
+	for rest != 0 {
 		// flood-fill 收集这个连通块
-		size := 0
-		stack := []HexCoord{c}
-		visited[c] = true
-		for len(stack) > 0 {
-			cur := stack[len(stack)-1]
-			stack = stack[:len(stack)-1]
-			size++
-			for _, nb := range b.Neighbors(cur) {
-				if !visited[nb] && b.Get(nb) == player {
-					visited[nb] = true
-					stack = append(stack, nb)
-				}
-			}
-		}
+		seed := rest & -rest
+		region := b.geom.floodFill(seed, rest)
+		rest &^= region
 		// 只要连通块大小 ≥3，就 +1
-		if size >= 3 {
+		if bits.OnesCount64(region) >= 3 {
 			blocks++
 		}
 	}
@@ -396,45 +260,11 @@ func evaluateHoles(b *Board, player CellState) int {
 	return holePenalty
 }
 
-// maxWeightedInfFromMoves：在不修改棋盘情况下，计算“加权”后的最大感染数
-func maxWeightedInfFromMoves(b *Board, pl CellState, moves []Move) int {
-	best := 0
-	for _, m := range moves {
-		// 直接数邻居感染数，无需克隆棋盘
-		cnt := previewInfectedCount(b, m, pl)
-
-		// 跳跃感染权重为 jumpInfWeight，克隆感染乘以 cloneInfWeight
-		if m.IsJump() {
-			cnt = cnt * jumpInfWeight
-		} else {
-			cnt = cnt * cloneInfWeight
-		}
-		if cnt > best {
-			best = cnt
-		}
-	}
-	return best
-}
-
 // “预览”一次感染数，而不实际修改棋盘
 func previewInfectedCount(b *Board, mv Move, player CellState) int {
-	count := 0
-	for _, dir := range Directions {
-		nb := mv.To.Add(dir)
-		if b.Get(nb) == Opponent(player) {
-			count++
-		}
+	i := b.geom.indexOf(mv.To)
+	if i < 0 {
+		return 0
 	}
-	return count
+	return bits.OnesCount64(b.geom.cloneMask[i] & b.mask(Opponent(player)))
 }
-
-// Predict 改为调用 CNN 的 value，失败则回退到静态评估
-//func Predict(b *Board, player CellState) int {
-//	if _, v, err := CNNPredict(b, player); err == nil {
-//		// value ∈ (-1,1) → 映射到分数区间（可按你原有量级调）
-//		fmt.Printf("模型错误 %v \n", err)
-//		return int(math.Round(float64(v) * 100.0))
-//	}
-//	// 回退：原 evaluateStatic
-//	return evaluateStatic(b, player)
-//}
@@ -0,0 +1,264 @@
+// internal/game/evaluator.go
+package game
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"hexxagon_go/internal/nnue"
+)
+
+// Evaluator 局面评估器：返回 player 视角的分数，越大越好，量级与静态评估相当（几十到几百）。
+// 实现要能被多个搜索线程同时调用。
+type Evaluator interface {
+	Name() string
+	Evaluate(b *Board, player CellState) int
+}
+
+// learnedEvaluator 可选：评估是学出来的，搜索里不再叠加跳跃罚分这类手调启发
+type learnedEvaluator interface {
+	learned() bool
+}
+
+// incrementalEvaluator 可选：能随走子增量更新（NNUE），搜索线程用它建累加器栈
+type incrementalEvaluator interface {
+	newAccStack(b *Board) accStack
+}
+
+// valueScoreScale 网络 value（[-1,1]）放大成整数分的倍数
+const valueScoreScale = 100
+
+// EvaluatorFactory 按参数创建评估器，arg 是 "名字:参数" 里冒号后的部分，可能为空
+type EvaluatorFactory func(arg string) (Evaluator, error)
+
+var (
+	evaluatorsMu sync.RWMutex
+	evaluators   = map[string]EvaluatorFactory{}
+)
+
+// RegisterEvaluator 注册一个评估器实现；名字重复会 panic
+func RegisterEvaluator(name string, f EvaluatorFactory) {
+	evaluatorsMu.Lock()
+	defer evaluatorsMu.Unlock()
+	if _, dup := evaluators[name]; dup {
+		panic("game: 评估器重复注册 " + name)
+	}
+	evaluators[name] = f
+}
+
+// EvaluatorNames 已注册的评估器名字，按字母序
+func EvaluatorNames() []string {
+	evaluatorsMu.RLock()
+	defer evaluatorsMu.RUnlock()
+	names := make([]string, 0, len(evaluators))
+	for n := range evaluators {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewEvaluator 按 "名字[:参数]" 创建评估器，例如 static、static:weights.json、cnn、cnn:model.onnx、
+// nnue:net.nnue、linear:linear.json。
+// 空串等同 static。
+func NewEvaluator(spec string) (Evaluator, error) {
+	name, arg, _ := strings.Cut(spec, ":")
+	if name == "" {
+		name = "static"
+	}
+	evaluatorsMu.RLock()
+	f, ok := evaluators[name]
+	evaluatorsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("未知的评估器 %q（可选 %s）", name, strings.Join(EvaluatorNames(), " / "))
+	}
+	ev, err := f(arg)
+	if err != nil {
+		return nil, fmt.Errorf("评估器 %s: %w", name, err)
+	}
+	return ev, nil
+}
+
+func init() {
+	RegisterEvaluator("static", func(arg string) (Evaluator, error) {
+		if arg == "" {
+			return staticEvaluator{}, nil
+		}
+		w, err := LoadEvalWeights(arg)
+		if err != nil {
+			return nil, err
+		}
+		return staticEvaluator{w: &w, name: "static:" + arg}, nil
+	})
+	RegisterEvaluator("cnn", newCNNEvaluator)
+	RegisterEvaluator("nnue", func(arg string) (Evaluator, error) {
+		net, err := loadNNUEArg(arg)
+		if err != nil {
+			return nil, err
+		}
+		return &NNUEEvaluator{Net: net, Source: arg}, nil
+	})
+	RegisterEvaluator("nnueq", func(arg string) (Evaluator, error) {
+		q, err := loadQNNUEArg(arg)
+		if err != nil {
+			return nil, err
+		}
+		return &NNUEEvaluator{QNet: q, Source: arg}, nil
+	})
+	RegisterEvaluator("linear", func(arg string) (Evaluator, error) {
+		if arg == "" {
+			return nil, fmt.Errorf("需要权重文件，如 linear:linear_eval.json")
+		}
+		return LoadLinearEvaluator(arg)
+	})
+}
+
+// —— static ——
+
+// staticEvaluator 启发式评估；w 为 nil 用默认权重
+type staticEvaluator struct {
+	w    *EvalWeights
+	name string
+}
+
+func (s staticEvaluator) Name() string {
+	if s.name == "" {
+		return "static"
+	}
+	return s.name
+}
+
+func (s staticEvaluator) Evaluate(b *Board, player CellState) int {
+	if s.w == nil {
+		return evaluateStatic(b, player)
+	}
+	return s.w.evaluate(b, player)
+}
+
+// —— cnn ——
+
+// cnnEvaluator 取 CNN 的 value 头；model 为 nil 时用 DefaultModel
+type cnnEvaluator struct {
+	model Model
+	name  string
+}
+
+// newCNNEvaluator arg 为空用全局模型，否则把它当 ONNX 路径单独加载一个
+func newCNNEvaluator(arg string) (Evaluator, error) {
+	if arg == "" {
+		if _, err := DefaultModel(); err != nil {
+			return nil, err
+		}
+		return &cnnEvaluator{name: "cnn"}, nil
+	}
+	m, err := LoadModel(ModelOptions{Path: arg})
+	if err != nil {
+		return nil, err
+	}
+	return &cnnEvaluator{model: m, name: "cnn:" + arg}, nil
+}
+
+func (c *cnnEvaluator) Name() string  { return c.name }
+func (c *cnnEvaluator) learned() bool { return true }
+
+func (c *cnnEvaluator) Evaluate(b *Board, player CellState) int {
+	if c.model == nil {
+		return EvaluateNN(b, player)
+	}
+	return modelScore(c.model, b, player)
+}
+
+// —— nnue ——
+
+// NNUEEvaluator 用 NNUE 评估，Net/QNet 二选一，都设时用 QNet（量化版）。
+// 搜索树里随走子增量更新累加器，零散调用（根排序等）才从头算。
+type NNUEEvaluator struct {
+	Net  *nnue.Net
+	QNet *nnue.QNet
+	// Source 权重文件路径，带进 Name（同 static:/linear:），分析缓存靠它区分不同的网络
+	Source string
+}
+
+func (e *NNUEEvaluator) Name() string {
+	name := "nnue"
+	if e.QNet != nil {
+		name = "nnueq"
+	}
+	if e.Source != "" {
+		name += ":" + e.Source
+	}
+	return name
+}
+
+func (e *NNUEEvaluator) learned() bool { return true }
+
+func (e *NNUEEvaluator) Evaluate(b *Board, player CellState) int {
+	if e.QNet != nil {
+		return QNNUEEval(e.QNet)(b, player)
+	}
+	return NNUEEval(e.Net)(b, player)
+}
+
+func (e *NNUEEvaluator) newAccStack(b *Board) accStack {
+	if e.QNet != nil {
+		return newNNUEStack[nnue.QAccumulator](e.QNet, b)
+	}
+	return newNNUEStack[nnue.Accumulator](e.Net, b)
+}
+
+func loadNNUEArg(path string) (*nnue.Net, error) {
+	if path == "" {
+		return nil, fmt.Errorf("需要权重文件，如 nnue:net.nnue")
+	}
+	net, err := nnue.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	if net.InputDim() != NNUEInputs {
+		return nil, fmt.Errorf("%s: 输入维度 %d，需要 %d", path, net.InputDim(), NNUEInputs)
+	}
+	return net, nil
+}
+
+// loadQNNUEArg 量化文件（HXNQ）直接读，浮点文件（HXNN）读进来再量化
+func loadQNNUEArg(path string) (*nnue.QNet, error) {
+	if path == "" {
+		return nil, fmt.Errorf("需要权重文件，如 nnueq:net.nnue")
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	magic := make([]byte, 4)
+	_, err = f.Read(magic)
+	f.Close()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if string(magic) != "HXNQ" {
+		net, err := loadNNUEArg(path)
+		if err != nil {
+			return nil, err
+		}
+		return nnue.Quantize(net), nil
+	}
+	q, err := nnue.LoadQuantized(path)
+	if err != nil {
+		return nil, err
+	}
+	if q.InputDim() != NNUEInputs {
+		return nil, fmt.Errorf("%s: 输入维度 %d，需要 %d", path, q.InputDim(), NNUEInputs)
+	}
+	return q, nil
+}
+
+// EvaluatorValue 把评估器包成 MCTS 的 ValueFunc：tanh(分数/staticValueScale)
+func EvaluatorValue(ev Evaluator) ValueFunc {
+	return func(b *Board, player CellState) float64 {
+		return math.Tanh(float64(ev.Evaluate(b, player)) / staticValueScale)
+	}
+}
@@ -0,0 +1,167 @@
+package game
+
+import (
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"hexxagon_go/internal/nnue"
+)
+
+// TestNewEvaluator 按名字建出各评估器，带文件的读临时文件；名字写错要报错
+func TestNewEvaluator(t *testing.T) {
+	dir := t.TempDir()
+	netPath := filepath.Join(dir, "net.nnue")
+	f, err := os.Create(netPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := nnue.Random(NNUEInputs, 1).Write(f); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	linPath := filepath.Join(dir, "linear.json")
+	if err := os.WriteFile(linPath, []byte(`{"weights":[0,1,0,0,0,0,0,0,0,0,0],"bias":0.5}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	gs := NewGameState(4)
+	for _, spec := range []string{"", "static", "nnue:" + netPath, "nnueq:" + netPath, "linear:" + linPath} {
+		ev, err := NewEvaluator(spec)
+		if err != nil {
+			t.Fatalf("%q: %v", spec, err)
+		}
+		if spec != "" && ev.Name() != spec {
+			t.Fatalf("%q: Name() = %q，应带上权重文件（分析缓存按它区分评估器）", spec, ev.Name())
+		}
+		e := NewEngine(EngineOptions{TTEntries: 1 << 10, Evaluator: ev, EndgameEmpties: -1})
+		if _, ok := e.FindBestMoveAtDepth(gs.Board, PlayerA, 2); !ok {
+			t.Fatalf("%s: 开局没搜出走法", ev.Name())
+		}
+	}
+	for _, spec := range []string{"nope", "nnue", "linear:" + netPath} {
+		if _, err := NewEvaluator(spec); err == nil {
+			t.Fatalf("%q 应报错", spec)
+		}
+	}
+}
+
+// TestLinearEvaluator 只有子数差权重时，分数 = (子数差 + b) × valueScoreScale，双方互为相反数（不计偏置）
+func TestLinearEvaluator(t *testing.T) {
+	ev := &LinearEvaluator{Bias: 0.5}
+	ev.Weights[1] = 1
+	gs := NewGameState(4)
+	moves := GenerateMoves(gs.Board, PlayerA)
+	gs.MakeMove(moves[0])
+	b := gs.Board
+	diff := b.CountPieces(PlayerA) - b.CountPieces(PlayerB)
+	if got, want := ev.Evaluate(b, PlayerA), int((float64(diff)+0.5)*valueScoreScale); got != want {
+		t.Fatalf("A 视角 %d，期望 %d", got, want)
+	}
+	if a, o := LinearFeatures(b, PlayerA), LinearFeatures(b, PlayerB); a[1] != -o[1] || a[2] != -o[2] {
+		t.Fatalf("子数差/外圈差应互为相反数: %v / %v", a, o)
+	}
+}
+
+// TestLinearFeaturesMatchExporter 与 features_exporter 实际导出的一行逐项比对。
+// 局面：种子 1 随机走 46 手，最后一手跳到角上 (4,-4)，棋盘外的三个邻格导出器按空格算
+func TestLinearFeaturesMatchExporter(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	gs := NewGameState(4)
+	for i := 0; i < 46; i++ {
+		mvs := GenerateMoves(gs.Board, gs.CurrentPlayer)
+		gs.MakeMove(mvs[r.Intn(len(mvs))])
+	}
+	if want := (Move{From: HexCoord{2, -2}, To: HexCoord{4, -4}}); gs.Board.LastMove != want {
+		t.Fatalf("局面变了：最后一手 %v，应为 %v", gs.Board.LastMove, want)
+	}
+	// 导出器（A=1 视角）对这个局面写出的行，去掉最后一列胜负标签
+	want := [LinearFeatureLen]float64{0.590164, 2, 3, 3, 41, 4, 180, 0, 0, 60, 1}
+	got := LinearFeatures(gs.Board, PlayerA)
+	for i := range want {
+		if math.Abs(got[i]-want[i]) > 1e-6 {
+			t.Errorf("%s: %v，导出器 %v", LinearFeatureNames[i], got[i], want[i])
+		}
+	}
+}
+
+// TestEvalWeightsFile 存盘读回不变；只写部分键时其余取默认，键写错报错；权重确实作用到评估上
+func TestEvalWeightsFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "w.json")
+	w := DefaultEvalWeights()
+	w.Piece = 9
+	if err := w.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := LoadEvalWeights(path); err != nil || got != w {
+		t.Fatalf("读回 %+v（%v），写入 %+v", got, err, w)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"piece": 0, "edge": 0, "block_diff": 0, "clone_inf": 0, "jump_inf": 0, "weak_jump": 0}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	only, err := LoadEvalWeights(path)
+	if err != nil || only.BlockFill != DefaultEvalWeights().BlockFill {
+		t.Fatalf("缺省键应保持默认: %+v（%v）", only, err)
+	}
+	gs := NewGameState(4)
+	gs.MakeMove(GenerateMoves(gs.Board, PlayerA)[0])
+	if got := WeightsEvaluator(only).Evaluate(gs.Board, PlayerA); got != 0 {
+		t.Fatalf("全零权重评估 %d", got)
+	}
+	if a, b := WeightsEvaluator(DefaultEvalWeights()).Evaluate(gs.Board, PlayerA), evaluateStatic(gs.Board, PlayerA); a != b {
+		t.Fatalf("默认权重 %d，evaluateStatic %d", a, b)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"pices": 3}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadEvalWeights(path); err == nil {
+		t.Fatal("写错的键应报错")
+	}
+	if _, err := WithWeights("nnue:x", path); err == nil {
+		t.Fatal("-weights 配非 static 评估器应报错")
+	}
+}
+
+func TestExplainEval(t *testing.T) {
+	gs := NewGameState(4)
+	for i := 0; i < 12 && !gs.GameOver; i++ {
+		mvs := GenerateMoves(gs.Board, gs.CurrentPlayer)
+		gs.MakeMove(mvs[(i*7)%len(mvs)])
+		for _, p := range []CellState{PlayerA, PlayerB} {
+			ex := ExplainEval(gs.Board, p)
+			if want := evaluateStatic(gs.Board, p); ex.Total != want {
+				t.Fatalf("第 %d 步 %v: Total %d，evaluateStatic %d\n%s", i, p, ex.Total, want, ex)
+			}
+			sum := 0
+			for _, term := range ex.Terms {
+				sum += term.Contribution
+			}
+			if sum != ex.Total {
+				t.Fatalf("各项之和 %d ≠ Total %d", sum, ex.Total)
+			}
+		}
+	}
+
+	// 填充率过阈值时连通块一项不计分，但特征值照样给出
+	w := DefaultEvalWeights()
+	w.BlockFill = 0
+	ex := w.Explain(gs.Board, PlayerA)
+	if ex.Terms[2].Name != "block_diff" || ex.Terms[2].Contribution != 0 || ex.Terms[2].Note == "" {
+		t.Fatalf("block_diff 应关掉: %+v", ex.Terms[2])
+	}
+	if ex.Total != w.evaluate(gs.Board, PlayerA) {
+		t.Fatalf("Total %d，evaluate %d", ex.Total, w.evaluate(gs.Board, PlayerA))
+	}
+	// 引擎报出自己用的权重，拆解才能和它的分数对上；学出来的评估器没有权重
+	if got, ok := NewEngine(EngineOptions{TTEntries: 1 << 10, Evaluator: WeightsEvaluator(w)}).EvalWeights(); !ok || got != w {
+		t.Fatalf("引擎权重 %+v（%v），应为 %+v", got, ok, w)
+	}
+	if _, ok := NewEngine(EngineOptions{TTEntries: 1 << 10, Evaluator: &LinearEvaluator{}}).EvalWeights(); ok {
+		t.Fatal("线性评估器不应报出静态权重")
+	}
+}
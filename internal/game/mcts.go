@@ -0,0 +1,400 @@
+// internal/game/mcts.go
+package game
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// PriorFunc 给 player 的每个候选走法一个先验（与 moves 等长，非负，不必归一化）
+type PriorFunc func(b *Board, player CellState, moves []Move) []float64
+
+// ValueFunc 估计 player 视角的局面价值，范围 [-1, 1]
+type ValueFunc func(b *Board, player CellState) float64
+
+const (
+	defaultPlayouts = 800
+	defaultCPuct    = 1.5
+	defaultDirEps   = 0.25
+	// staticValueScale 没有 ONNX 时用 tanh(静态评估/scale) 顶替 value 头
+	staticValueScale = 300.0
+)
+
+// MCTSOptions PUCT 搜索参数；零值字段用默认值
+type MCTSOptions struct {
+	Playouts       int     // 每步模拟次数上限；<=0 用 defaultPlayouts
+	CPuct          float64 // 探索常数；<=0 用 defaultCPuct
+	DirichletAlpha float64 // 根节点 Dirichlet 噪声的 α；<=0 不加噪声（对弈用），自对弈常用 0.3
+	DirichletEps   float64 // 噪声所占比例；<=0 用 defaultDirEps
+	Temperature    float64 // 按 访问数^(1/T) 抽样选着；0 直接取访问最多的
+	NoReuse        bool    // true 时每步都重建搜索树，默认复用上一步的子树
+	Seed           int64   // 随机源种子；0 用当前时间
+
+	Priors PriorFunc // nil 用 CNN policy 头
+	Value  ValueFunc // nil 用 CNN value 头（网络不可用时退回静态评估）
+}
+
+// MCTS 是 PUCT 蒙特卡洛树搜索玩家，和 Engine 一样实现 Searcher。
+// 自带搜索树，同一个 MCTS 不支持多个 goroutine 同时调用 Search。
+type MCTS struct {
+	opts MCTSOptions
+	rng  *rand.Rand
+	root *mctsNode // 上一次搜索的根，供下一步复用
+
+	nnErrs int   // 网络推理失败、退回静态评估的叶子数
+	nnErr  error // 最近一次推理错误
+}
+
+// mctsNode 树节点。value 一律记在“走到本节点的那一方”的视角下，
+// 这样父节点挑子节点时直接比较子节点的 Q 即可。
+type mctsNode struct {
+	move      Move      // 走到本节点的那一步
+	side      CellState // 本节点的行棋方
+	key       uint64    // 棋盘哈希 ^ 行棋方，树复用时用来认局面
+	prior     float64   // 当前使用的先验（根节点可能混了噪声）
+	basePrior float64   // 网络给的原始先验
+	visits    int
+	valueSum  float64
+	children  []*mctsNode
+	expanded  bool
+	terminal  bool
+	termValue float64 // 终局时 side 视角的结果
+}
+
+func (n *mctsNode) q() float64 {
+	if n.visits == 0 {
+		return 0
+	}
+	return n.valueSum / float64(n.visits)
+}
+
+// NewMCTS 按 opts 创建 MCTS 玩家
+func NewMCTS(opts MCTSOptions) *MCTS {
+	if opts.Playouts <= 0 {
+		opts.Playouts = defaultPlayouts
+	}
+	if opts.CPuct <= 0 {
+		opts.CPuct = defaultCPuct
+	}
+	if opts.DirichletEps <= 0 {
+		opts.DirichletEps = defaultDirEps
+	}
+	seed := opts.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &MCTS{opts: opts, rng: rand.New(rand.NewSource(seed))}
+}
+
+// Search 在 root 上做至多 Playouts 次模拟，受 ctx 与 limits 的时间预算约束（MaxDepth 不起作用）。
+// 返回的 Score 为所选走法的平均价值 ×100，Depth 为主变例长度，Nodes 为本次模拟次数。
+func (m *MCTS) Search(ctx context.Context, root *Board, player CellState, limits SearchLimits) SearchResult {
+	start := time.Now()
+	if budget := limits.Budget(); budget > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, budget)
+		defer cancel()
+	}
+
+	m.root = m.reuse(root, player)
+	b := cloneBoard(root)
+	if !m.root.expanded {
+		m.expand(m.root, b)
+	}
+	if m.root.terminal {
+		m.root = nil
+		return SearchResult{Time: time.Since(start)}
+	}
+	m.addNoise(m.root)
+
+	playouts := 0
+	for playouts < m.opts.Playouts {
+		// 至少模拟一次，保证有结果
+		if playouts > 0 && ctx.Err() != nil {
+			break
+		}
+		m.playout(b)
+		playouts++
+	}
+
+	best := m.pick(m.root)
+	pv := m.pv(best)
+	return SearchResult{
+		Move:  best.move,
+		Score: int(best.q() * 100),
+		Depth: len(pv),
+		Nodes: uint64(playouts),
+		PV:    pv,
+		Time:  time.Since(start),
+		OK:    true,
+	}
+}
+
+// reuse 在上次的树里找 root：可能就是旧根，也可能是旧根的孙子（我走一步、对手走一步）
+func (m *MCTS) reuse(root *Board, player CellState) *mctsNode {
+	key := root.hash ^ zobristSide[sideIdx(player)]
+	if old := m.root; old != nil && !m.opts.NoReuse {
+		if old.key == key {
+			return old
+		}
+		for _, c := range old.children {
+			for _, g := range c.children {
+				if g.key == key {
+					return g
+				}
+			}
+		}
+	}
+	return &mctsNode{side: player, key: key}
+}
+
+// playout 从根选到叶子、扩展、评估并回传；b 用完即还原
+func (m *MCTS) playout(b *Board) {
+	n := m.root
+	path := []*mctsNode{n}
+	undos := make([]undoInfo, 0, 16)
+	for n.expanded && !n.terminal {
+		n = m.selectChild(n)
+		undos = append(undos, mMakeMoveWithUndo(b, n.move, Opponent(n.side)))
+		path = append(path, n)
+	}
+
+	var v float64 // 叶子行棋方视角
+	if n.terminal {
+		v = n.termValue
+	} else {
+		v = m.expand(n, b)
+	}
+	for i := len(path) - 1; i >= 0; i-- {
+		// 节点记“走到它的一方”的视角，也就是行棋方的相反数
+		path[i].visits++
+		path[i].valueSum -= v
+		v = -v
+	}
+	for i := len(undos) - 1; i >= 0; i-- {
+		b.UnmakeMove(undos[i])
+	}
+}
+
+// selectChild PUCT：Q + c·P·√N/(1+n)
+func (m *MCTS) selectChild(n *mctsNode) *mctsNode {
+	sqrtN := math.Sqrt(float64(n.visits))
+	var best *mctsNode
+	bestScore := math.Inf(-1)
+	for _, c := range n.children {
+		u := c.q() + m.opts.CPuct*c.prior*sqrtN/float64(1+c.visits)
+		if u > bestScore {
+			best, bestScore = c, u
+		}
+	}
+	return best
+}
+
+// expand 生成子节点并返回 n 行棋方视角的叶子价值；无棋可走时把 n 标成终局
+func (m *MCTS) expand(n *mctsNode, b *Board) float64 {
+	n.expanded = true
+	moves := GenerateMoves(b, n.side)
+	empties := b.CountPieces(Empty)
+	if len(moves) == 0 || empties == 0 {
+		// 终局：棋盘已满，或 side 无棋可走、剩余空格归对方
+		diff := b.CountPieces(n.side) - b.CountPieces(Opponent(n.side))
+		if len(moves) == 0 {
+			diff -= empties
+		}
+		n.terminal = true
+		n.termValue = float64(sign(diff))
+		return n.termValue
+	}
+
+	priors, value := m.evaluate(b, n.side, moves)
+	var sum float64
+	for _, p := range priors {
+		sum += p
+	}
+	next := Opponent(n.side)
+	n.children = make([]*mctsNode, len(moves))
+	for i, mv := range moves {
+		p := 1 / float64(len(moves))
+		if sum > 0 {
+			p = priors[i] / sum
+		}
+		undo := mMakeMoveWithUndo(b, mv, n.side)
+		n.children[i] = &mctsNode{move: mv, side: next, key: b.hash ^ zobristSide[sideIdx(next)], prior: p, basePrior: p}
+		b.UnmakeMove(undo)
+	}
+	return value
+}
+
+// evaluate 给叶子的先验和价值；两个都没覆盖时只跑一次网络，两个头一起用
+func (m *MCTS) evaluate(b *Board, player CellState, moves []Move) ([]float64, float64) {
+	if m.opts.Priors == nil && m.opts.Value == nil {
+		priors, value, err := nnEvaluate(b, player, moves)
+		if err != nil {
+			m.nnErrs++
+			m.nnErr = err
+		}
+		return priors, value
+	}
+	prior, value := m.opts.Priors, m.opts.Value
+	if prior == nil {
+		prior = nnPriors
+	}
+	if value == nil {
+		value = nnValue
+	}
+	return prior(b, player, moves), value(b, player)
+}
+
+// addNoise 根节点先验混入 Dirichlet(α) 噪声；复用的根从原始先验重新混
+func (m *MCTS) addNoise(root *mctsNode) {
+	if m.opts.DirichletAlpha <= 0 || len(root.children) == 0 {
+		return
+	}
+	noise := make([]float64, len(root.children))
+	var sum float64
+	for i := range noise {
+		noise[i] = sampleGamma(m.rng, m.opts.DirichletAlpha)
+		sum += noise[i]
+	}
+	eps := m.opts.DirichletEps
+	for i, c := range root.children {
+		c.prior = (1-eps)*c.basePrior + eps*noise[i]/sum
+	}
+}
+
+// pick 按温度选根走法：T=0 取访问最多的，否则按 访问数^(1/T) 抽样
+func (m *MCTS) pick(root *mctsNode) *mctsNode {
+	best := root.children[0]
+	for _, c := range root.children[1:] {
+		if c.visits > best.visits {
+			best = c
+		}
+	}
+	if m.opts.Temperature <= 0 {
+		return best
+	}
+	weights := make([]float64, len(root.children))
+	var sum float64
+	for i, c := range root.children {
+		weights[i] = math.Pow(float64(c.visits), 1/m.opts.Temperature)
+		sum += weights[i]
+	}
+	if sum == 0 {
+		return best
+	}
+	x := m.rng.Float64() * sum
+	for i, w := range weights {
+		if x < w {
+			return root.children[i]
+		}
+		x -= w
+	}
+	return best
+}
+
+// pv 从 n 开始沿访问最多的子节点走下去
+func (m *MCTS) pv(n *mctsNode) []Move {
+	var line []Move
+	for n != nil && n.visits > 0 {
+		line = append(line, n.move)
+		var next *mctsNode
+		for _, c := range n.children {
+			if c.visits > 0 && (next == nil || c.visits > next.visits) {
+				next = c
+			}
+		}
+		n = next
+	}
+	return line
+}
+
+// NNErrors 返回默认网络推理失败的次数和最近一次的错误；失败的叶子用静态评估顶替
+func (m *MCTS) NNErrors() (int, error) {
+	return m.nnErrs, m.nnErr
+}
+
+// nnEvaluate 默认的先验和价值，一次 Predict 同时取 policy 头和 value 头；
+// 网络加载失败时先验均匀、价值退回静态评估，推理失败同样退回并返回错误
+func nnEvaluate(b *Board, player CellState, moves []Move) ([]float64, float64, error) {
+	m, err := DefaultModel()
+	if err == nil {
+		logits, v, perr := m.Predict(b, player)
+		if perr == nil {
+			return policyPriors(logits, moves), float64(v), nil
+		}
+		err = perr
+	} else {
+		err = nil // 没有网络是预期情况，不算推理错误
+	}
+	return policyPriors(nil, moves), math.Tanh(float64(evaluateStatic(b, player)) / staticValueScale), err
+}
+
+// nnPriors 只要先验时用：CNN policy 头
+func nnPriors(b *Board, player CellState, moves []Move) []float64 {
+	logits, err := PolicyNN(b, player)
+	if err != nil {
+		logits = nil
+	}
+	return policyPriors(logits, moves)
+}
+
+// policyPriors 在合法走法上对 logits 做 softmax；没有 logits 就均匀分布
+func policyPriors(logits []float32, moves []Move) []float64 {
+	out := make([]float64, len(moves))
+	if len(logits) == 0 {
+		for i := range out {
+			out[i] = 1
+		}
+		return out
+	}
+	maxL := math.Inf(-1)
+	for _, mv := range moves {
+		maxL = math.Max(maxL, float64(logits[MoveToPolicyIndex(mv)]))
+	}
+	for i, mv := range moves {
+		out[i] = math.Exp(float64(logits[MoveToPolicyIndex(mv)]) - maxL)
+	}
+	return out
+}
+
+// nnValue 只要价值时用：CNN value 头；onnxruntime 和纯 Go 权重都没有时退回静态评估
+func nnValue(b *Board, player CellState) float64 {
+	if _, err := DefaultModel(); err != nil {
+		return math.Tanh(float64(evaluateStatic(b, player)) / staticValueScale)
+	}
+	return float64(EvaluateNN(b, player)) / 100
+}
+
+// sampleGamma 用 Marsaglia-Tsang 方法采样 Gamma(α, 1)
+func sampleGamma(r *rand.Rand, alpha float64) float64 {
+	if alpha < 1 {
+		// Gamma(α) = Gamma(α+1) · U^(1/α)
+		return sampleGamma(r, alpha+1) * math.Pow(r.Float64(), 1/alpha)
+	}
+	d := alpha - 1.0/3
+	c := 1 / math.Sqrt(9*d)
+	for {
+		x := r.NormFloat64()
+		v := 1 + c*x
+		if v <= 0 {
+			continue
+		}
+		v = v * v * v
+		u := r.Float64()
+		if math.Log(u) < 0.5*x*x+d-d*v+d*math.Log(v) {
+			return d * v
+		}
+	}
+}
+
+func sign(x int) int {
+	switch {
+	case x > 0:
+		return 1
+	case x < 0:
+		return -1
+	}
+	return 0
+}
@@ -0,0 +1,152 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+)
+
+// TestMCTSFindsWinningClone 克隆进中心吃光 B 立即获胜，另一步则会被 B 反吃
+func TestMCTSFindsWinningClone(t *testing.T) {
+	b := NewBoard(2)
+	for _, c := range b.AllCoords() {
+		_ = b.Set(c, Blocked)
+	}
+	for _, c := range []HexCoord{{0, -1}, {-1, 0}, {-1, 1}, {0, 1}, {1, -1}} {
+		_ = b.Set(c, PlayerB)
+	}
+	_ = b.Set(HexCoord{0, 0}, Empty)
+	_ = b.Set(HexCoord{2, 0}, Empty)
+	_ = b.Set(HexCoord{-2, 0}, Empty)
+	_ = b.Set(HexCoord{1, 0}, PlayerA)
+
+	m := NewMCTS(MCTSOptions{Playouts: 200, Seed: 1})
+	res := m.Search(context.Background(), b, PlayerA, SearchLimits{})
+	if !res.OK || res.Move != (Move{HexCoord{1, 0}, HexCoord{0, 0}}) {
+		t.Fatalf("应克隆到中心吃光 B，得到 %+v", res)
+	}
+	if res.Score != 100 {
+		t.Fatalf("必胜着的价值应为 100，得到 %d", res.Score)
+	}
+}
+
+// TestMCTSReusesTree 我方和对手各走一步后，新根应来自上一步的树
+func TestMCTSReusesTree(t *testing.T) {
+	gs := NewGameState(3)
+	m := NewMCTS(MCTSOptions{Playouts: 300, Seed: 2, DirichletAlpha: 0.3})
+	res := m.Search(context.Background(), gs.Board, gs.CurrentPlayer, SearchLimits{})
+	if !res.OK || len(res.PV) < 2 {
+		t.Fatalf("PV 太短: %+v", res)
+	}
+	gs.MakeMove(res.PV[0])
+	gs.MakeMove(res.PV[1])
+
+	var want *mctsNode
+	for _, c := range m.root.children {
+		if c.move == res.PV[0] {
+			for _, g := range c.children {
+				if g.move == res.PV[1] {
+					want = g
+				}
+			}
+		}
+	}
+	if want == nil || want.visits == 0 {
+		t.Fatal("沿 PV 的孙节点应已被访问过")
+	}
+	before := want.visits
+
+	res2 := m.Search(context.Background(), gs.Board, gs.CurrentPlayer, SearchLimits{})
+	if m.root != want {
+		t.Fatal("第二次搜索没有复用子树")
+	}
+	if got := m.root.visits; got != before+int(res2.Nodes) {
+		t.Fatalf("根访问数 %d，期望 %d+%d", got, before, res2.Nodes)
+	}
+}
+
+// countingModel 数 Predict 调用次数，策略全 0、价值固定
+type countingModel struct{ calls int }
+
+func (m *countingModel) Predict(b *Board, side CellState) ([]float32, float32, error) {
+	m.calls++
+	return make([]float32, PolicyLen), 0.25, nil
+}
+
+func (m *countingModel) Info() ModelInfo { return ModelInfo{Backend: "go", Source: "test"} }
+func (m *countingModel) Close() error    { return nil }
+
+// TestMCTSOnePredictPerExpansion 默认先验/价值时每次展开只跑一次网络
+func TestMCTSOnePredictPerExpansion(t *testing.T) {
+	cm := &countingModel{}
+	SetDefaultModel(cm)
+	defer SetDefaultModel(nil)
+
+	gs := NewGameState(3)
+	m := NewMCTS(MCTSOptions{Playouts: 50, Seed: 3, NoReuse: true})
+	res := m.Search(context.Background(), gs.Board, gs.CurrentPlayer, SearchLimits{})
+	if !res.OK {
+		t.Fatalf("搜索失败: %+v", res)
+	}
+	expanded := 0
+	var walk func(n *mctsNode)
+	walk = func(n *mctsNode) {
+		if n.expanded && !n.terminal {
+			expanded++
+		}
+		for _, c := range n.children {
+			walk(c)
+		}
+	}
+	walk(m.root)
+	if cm.calls != expanded {
+		t.Fatalf("展开 %d 个节点，Predict 调了 %d 次", expanded, cm.calls)
+	}
+}
+
+// failingModel 每次 Predict 都报错
+type failingModel struct{}
+
+var errPredict = errors.New("predict failed")
+
+func (failingModel) Predict(b *Board, side CellState) ([]float32, float32, error) {
+	return nil, 0, errPredict
+}
+
+func (failingModel) Info() ModelInfo { return ModelInfo{Backend: "go", Source: "test"} }
+func (failingModel) Close() error    { return nil }
+
+// TestMCTSPredictErrorFallsBack 推理失败时价值退回静态评估，并计入 NNErrors
+func TestMCTSPredictErrorFallsBack(t *testing.T) {
+	SetDefaultModel(failingModel{})
+	defer SetDefaultModel(nil)
+
+	gs := NewGameState(3)
+	gs.Board.ApplyMove(GenerateMoves(gs.Board, PlayerA)[0], PlayerA) // 打破对称，静态评估不为 0
+	want := math.Tanh(float64(evaluateStatic(gs.Board, PlayerB)) / staticValueScale)
+	if want == 0 {
+		t.Fatal("测试局面静态评估为 0，区分不出退回")
+	}
+	m := NewMCTS(MCTSOptions{Playouts: 20, Seed: 3, NoReuse: true})
+	moves := GenerateMoves(gs.Board, PlayerB)
+	priors, v := m.evaluate(gs.Board, PlayerB, moves)
+	if v != want {
+		t.Fatalf("价值 %v，期望静态退回 %v", v, want)
+	}
+	if len(priors) != len(moves) {
+		t.Fatalf("先验 %d 个，着法 %d 个", len(priors), len(moves))
+	}
+	n, err := m.NNErrors()
+	if n != 1 || !errors.Is(err, errPredict) {
+		t.Fatalf("NNErrors = %d, %v", n, err)
+	}
+
+	res := m.Search(context.Background(), gs.Board, PlayerB, SearchLimits{})
+	if !res.OK {
+		t.Fatalf("搜索失败: %+v", res)
+	}
+	if n2, _ := m.NNErrors(); n2 <= n {
+		t.Fatalf("搜索后 NNErrors 没有增加: %d", n2)
+	}
+}
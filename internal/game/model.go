@@ -0,0 +1,158 @@
+// internal/game/model.go
+package game
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Model 策略/价值网络。policy 按 MoveToPolicyIndex 索引、长度 PolicyLen，未做 softmax；
+// value 为 side 视角，范围 [-1, 1]。实现要能被多个 goroutine 同时调用。
+type Model interface {
+	Predict(b *Board, side CellState) (policy []float32, value float32, err error)
+	Info() ModelInfo
+	Close() error
+}
+
+// ModelInfo 模型来源与元数据，元数据读不到的字段留空
+type ModelInfo struct {
+	Backend string // "onnx" 或 "go"
+	Source  string // 文件路径，或 "embedded"
+
+	// 实际使用的 I/O 名（纯 Go 后端为空）
+	Input, Policy, Value string
+
+	Producer    string
+	Graph       string
+	Description string
+	Version     int64
+	Custom      map[string]string // 导出脚本写入的自定义键值，如 channels/blocks
+}
+
+// 环境变量，均可被 ModelOptions 里对应的字段覆盖
+const (
+	onnxPathEnv  = "HEX_ONNX_PATH"  // 外部 ONNX 模型路径，优先于内嵌模型
+	onnxCUDAEnv  = "HEX_ONNX_CUDA"  // "1" 启用 CUDA EP（需要 onnxruntime-gpu），失败退回 CPU
+	nnBackendEnv = "HEX_NN_BACKEND" // onnx / go，空为自动
+)
+
+// ModelOptions LoadModel 的参数；零值表示全部按环境变量和内嵌资源来
+type ModelOptions struct {
+	Backend string // "onnx" 只用 onnxruntime，"go" 只用纯 Go 前向，空则先 onnx 后 go
+
+	Path string // ONNX 文件；空则读 HEX_ONNX_PATH，再空用内嵌的 hex_cnn.onnx
+	Data []byte // ONNX 模型字节，非空时优先于 Path
+	CUDA bool   // 也可设置 HEX_ONNX_CUDA=1
+
+	// I/O 名，空则按模型自动识别：输入 state/x，策略 policy/logits，价值 value
+	InputName, PolicyName, ValueName string
+
+	GoWeights string // 纯 Go 权重文件；空则读 HEX_CNN_WEIGHTS，再空用内嵌的 hex_cnn.bin
+
+	Sessions int // onnxruntime 会话池大小；<=0 为 1
+	Threads  int // 每个会话的 intra-op 线程数；<=0 用 onnxruntime 默认
+
+	// Batch>1 时用 Batcher 包一层：并发的 Predict 在 BatchWindow 内凑成最多 Batch 个一起跑
+	Batch       int
+	BatchWindow time.Duration
+}
+
+// LoadModel 按 opts 加载模型。自动模式下 onnxruntime 起不来会退到纯 Go 前向，两个都失败才返回错误。
+func LoadModel(opts ModelOptions) (Model, error) {
+	m, err := loadBackend(opts)
+	if err != nil || opts.Batch <= 1 {
+		return m, err
+	}
+	return NewBatcher(m, BatcherOptions{MaxBatch: opts.Batch, Window: opts.BatchWindow, Workers: opts.Sessions}), nil
+}
+
+func loadBackend(opts ModelOptions) (Model, error) {
+	backend := opts.Backend
+	if backend == "" {
+		backend = os.Getenv(nnBackendEnv)
+	}
+	switch backend {
+	case "onnx":
+		return loadONNXModel(opts)
+	case "go":
+		return loadGoModel(opts)
+	case "":
+		m, onnxErr := loadONNXModel(opts)
+		if onnxErr == nil {
+			return m, nil
+		}
+		m, goErr := loadGoModel(opts)
+		if goErr != nil {
+			return nil, fmt.Errorf("onnxruntime: %v；纯 Go: %w", onnxErr, goErr)
+		}
+		return m, nil
+	}
+	return nil, fmt.Errorf("未知的推理后端 %q（可选 onnx / go）", backend)
+}
+
+var (
+	defaultModelMu   sync.Mutex
+	defaultModel     Model
+	defaultModelErr  error
+	defaultModelDone bool
+)
+
+// DefaultModel 引擎用的全局模型，第一次调用时按 LoadModel(ModelOptions{}) 加载
+func DefaultModel() (Model, error) {
+	defaultModelMu.Lock()
+	defer defaultModelMu.Unlock()
+	if !defaultModelDone {
+		defaultModel, defaultModelErr = LoadModel(ModelOptions{})
+		defaultModelDone = true
+	}
+	return defaultModel, defaultModelErr
+}
+
+// SetDefaultModel 替换全局模型（工具里用 -model 指定文件时用），旧模型由调用方负责关闭；
+// 传 nil 则下次 DefaultModel 重新按默认方式加载
+func SetDefaultModel(m Model) {
+	defaultModelMu.Lock()
+	defer defaultModelMu.Unlock()
+	defaultModel, defaultModelErr, defaultModelDone = m, nil, m != nil
+}
+
+// valuePredictor 可选：只要 value 时能省掉策略头的实现
+type valuePredictor interface {
+	predictValue(b *Board, side CellState) float32
+}
+
+// 只取 value 头做静态评估（返回 int，方便接到你的评分框架）
+func EvaluateNN(b *Board, me CellState) int {
+	m, err := DefaultModel()
+	if err != nil {
+		// 回退到旧静态评估也行：
+		// return evaluateStatic(b, me)
+		return 0
+	}
+	return modelScore(m, b, me)
+}
+
+// modelScore value 范围(-1,1)，放大到可比较的整数
+func modelScore(m Model, b *Board, me CellState) int {
+	if vp, ok := m.(valuePredictor); ok {
+		return int(vp.predictValue(b, me) * valueScoreScale)
+	}
+	_, v, err := m.Predict(b, me)
+	if err != nil {
+		return 0
+	}
+	return int(v * valueScoreScale)
+}
+
+// 可选：拿策略头（PolicyLen 个 logits，按 MoveToPolicyIndex 取值，自己在 Go 侧做 mask/softmax/挑选）
+func PolicyNN(b *Board, me CellState) ([]float32, error) {
+	m, err := DefaultModel()
+	if err != nil {
+		return nil, err
+	}
+	logits, _, err := m.Predict(b, me)
+	// 这里不做 softmax；若需要概率，再减去 max 然后做 exp/sum
+	return logits, err
+}
@@ -80,22 +80,18 @@ func (m Move) IsJumpOld() bool {
 	}
 	return false
 }
+
+// GenerateMoves 枚举玩家 player 在棋盘 b 上所有合法走法。
+// 起点、终点均按格子编号升序，克隆与跳跃落点混合排列。
 func GenerateMoves(b *Board, player CellState) []Move {
 	var moves []Move
-	for _, from := range b.AllCoords() {
-		if b.Get(from) != player {
-			continue
-		}
-		for _, to := range b.AllCoords() {
-			if b.Get(to) != Empty {
-				continue
-			}
-			switch HexDist(from, to) {
-			case 1, 2:
-				moves = append(moves, Move{From: from, To: to})
-			}
-		}
-	}
+	g := b.geom
+	empty := b.mask(Empty)
+	forEachBit(b.mask(player), func(from int) {
+		forEachBit((g.cloneMask[from]|g.jumpMask[from])&empty, func(to int) {
+			moves = append(moves, Move{From: g.coords[from], To: g.coords[to]})
+		})
+	})
 	return moves
 }
 
@@ -167,3 +163,22 @@ func IsLegalMove(from, to HexCoord) (clone, jump bool) {
 	d := HexDist(from, to)
 	return d == 1, d == 2
 }
+
+// moveToFront 若 moves 中有 m，把它与 moves[0] 交换
+func moveToFront(moves []Move, m Move) {
+	for i := range moves {
+		if moves[i] == m {
+			moves[0], moves[i] = moves[i], moves[0]
+			return
+		}
+	}
+}
+
+// isLegal 判断 m 在 b 上是否是 player 的合法走法
+func isLegal(b *Board, m Move, player CellState) bool {
+	if b.Get(m.From) != player || b.Get(m.To) != Empty {
+		return false
+	}
+	d := HexDist(m.From, m.To)
+	return d == 1 || d == 2
+}
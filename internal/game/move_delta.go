@@ -19,7 +19,7 @@ func (m Move) MakeMove(b *Board, player CellState) (infectedCoords []HexCoord, u
 
 	// 内部 helper：写格子并记 prev
 	set := func(c HexCoord, s CellState) {
-		prev := b.cells[c]
+		prev := b.Get(c)
 		if prev == s {
 			return
 		}
@@ -36,13 +36,15 @@ func (m Move) MakeMove(b *Board, player CellState) (infectedCoords []HexCoord, u
 	set(m.To, player)
 
 	// --- 3. 感染邻格，并记录坐标 ---
-	for _, n := range b.Neighbors(m.To) {
-		if b.Get(n) == Opponent(player) {
-			set(n, player)
-			infectedCoords = append(infectedCoords, n)
-		}
-	}
-
+	g := b.geom
+	victims := g.cloneMask[g.indexOf(m.To)] & b.mask(Opponent(player))
+	forEachBit(victims, func(i int) {
+		n := g.coords[i]
+		set(n, player)
+		infectedCoords = append(infectedCoords, n)
+	})
+
+	b.checkHash("MakeMove")
 	return infectedCoords, undo
 }
 
@@ -53,4 +55,5 @@ func (b *Board) UnmakeMove(u undoInfo) {
 		c := u.changed[i]
 		b.set(c.coord, c.prev)
 	}
+	b.checkHash("UnmakeMove")
 }
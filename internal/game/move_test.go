@@ -1,6 +1,9 @@
 package game
 
-import "testing"
+import (
+	"math/rand"
+	"testing"
+)
 
 func TestJumpOverObstacle(t *testing.T) {
 	// 1) 新建半径 2 的小棋盘（足够测试）
@@ -25,3 +28,76 @@ func TestJumpOverObstacle(t *testing.T) {
 		t.Errorf("期望能跳过障碍：%v 应该在 moves 里，但没找到", want)
 	}
 }
+
+// TestGenerateMovesMatchesDirScan 随机对局中，位棋盘走法生成应与逐方向扫描的旧实现一致
+func TestGenerateMovesMatchesDirScan(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	gs := NewGameState(4)
+	for ply := 0; ply < 60 && !gs.GameOver; ply++ {
+		for _, pl := range []CellState{PlayerA, PlayerB} {
+			got := GenerateMoves(gs.Board, pl)
+			want := GenerateMovesOld(gs.Board, pl)
+			if len(got) != len(want) {
+				t.Fatalf("ply %d %v: 走法数 %d，期望 %d", ply, pl, len(got), len(want))
+			}
+			set := make(map[Move]bool, len(want))
+			for _, m := range want {
+				set[m] = true
+			}
+			for _, m := range got {
+				if !set[m] {
+					t.Fatalf("ply %d %v: 多出走法 %v", ply, pl, m)
+				}
+			}
+		}
+		moves := GenerateMoves(gs.Board, gs.CurrentPlayer)
+		if _, _, err := gs.MakeMove(moves[r.Intn(len(moves))]); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// TestPolicyIndexRoundTrip 整盘所有几何上可能的走法：索引互不相同、落在 [0,PolicyLen) 且能还原
+func TestPolicyIndexRoundTrip(t *testing.T) {
+	b := NewBoard(4)
+	seen := make(map[int]Move)
+	for _, from := range b.AllCoords() {
+		for _, d := range append(append([]HexCoord{}, cloneDirs...), jumpDirs...) {
+			to := HexCoord{from.Q + d.Q, from.R + d.R}
+			if !b.InBounds(to) {
+				continue
+			}
+			m := Move{From: from, To: to}
+			i := MoveToPolicyIndex(m)
+			if i < 0 || i >= PolicyLen {
+				t.Fatalf("%v 的索引 %d 越界", m, i)
+			}
+			if old, dup := seen[i]; dup {
+				t.Fatalf("%v 与 %v 撞了索引 %d", m, old, i)
+			}
+			seen[i] = m
+			if back := PolicyIndexToMove(i); back != m {
+				t.Fatalf("索引 %d 还原成 %v，期望 %v", i, back, m)
+			}
+		}
+	}
+}
+
+// TestDecodeBoardTensor 编码再解码（两个视角）得到同一个棋盘
+func TestDecodeBoardTensor(t *testing.T) {
+	gs := NewGameState(4)
+	r := rand.New(rand.NewSource(5))
+	for i := 0; i < 12; i++ {
+		moves := GenerateMoves(gs.Board, gs.CurrentPlayer)
+		gs.MakeMove(moves[r.Intn(len(moves))])
+	}
+	for _, me := range []CellState{PlayerA, PlayerB} {
+		enc := EncodeBoardTensor(gs.Board, me)
+		b := DecodeBoardTensor(enc[:], me)
+		for _, c := range gs.Board.AllCoords() {
+			if got, want := b.Get(c), gs.Board.Get(c); got != want {
+				t.Fatalf("视角 %v 格子 %v: 解码 %v，原来 %v", me, c, got, want)
+			}
+		}
+	}
+}
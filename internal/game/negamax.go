@@ -0,0 +1,347 @@
+// internal/game/negamax.go
+package game
+
+import (
+	"sort"
+	"sync/atomic"
+)
+
+// EvalFunc 静态评估：返回 player 视角的分数，越大越好
+type EvalFunc func(b *Board, player CellState) int
+
+const (
+	maxPly     = 64                                      // 杀手着表的层数上限
+	maxCells   = 3*maxBoardRadius*(maxBoardRadius+1) + 1 // 最大半径的格子数（61）
+	historyMax = 1 << 20                                 // 历史分超过它就整体减半
+	searchInf  = 1 << 30                                 // α-β 的无穷大，加减罚分也不会溢出
+	killerKey  = 1 << 21                                 // 杀手着的排序分，低于感染着、高于历史分
+	infectKey  = 1 << 22                                 // 有感染的走法排在最前（TT 着除外）
+	ttMoveKey  = 1 << 30                                 // 置换表最佳着永远第一
+)
+
+// searchThread 是单个搜索 goroutine 的私有状态，不与其他 goroutine 共享，无需加锁
+type searchThread struct {
+	killers [maxPly][2]Move               // 每层最近两个引起 β 截断的走法
+	history [2][maxCells * maxCells]int32 // [行棋方][from*maxCells+to] 截断累计分
+	nn      accStack                      // 引擎配了 NNUE 时的增量累加器
+	halt    *atomic.Bool                  // Lazy SMP 辅助线程的停止信号；主线程为 nil
+}
+
+func newSearchThread() *searchThread {
+	return &searchThread{}
+}
+
+// newThread 为从 b 开始的一次搜索建线程状态；配了 NNUE 就按 b 初始化累加器
+func (e *Engine) newThread(b *Board) *searchThread {
+	t := newSearchThread()
+	if e.inc != nil {
+		t.nn = e.inc.newAccStack(b)
+	}
+	return t
+}
+
+// aborted 搜索被取消，或本线程是已经用不着的辅助线程
+func (e *Engine) aborted(t *searchThread) bool {
+	return e.stop.Load() || (t.halt != nil && t.halt.Load())
+}
+
+// makeMove / unmakeMove 走子并同步 NNUE 累加器
+func (t *searchThread) makeMove(b *Board, mv Move, side CellState) undoInfo {
+	u := mMakeMoveWithUndo(b, mv, side)
+	if t.nn != nil {
+		t.nn.push(b, u)
+	}
+	return u
+}
+
+func (t *searchThread) unmakeMove(b *Board, u undoInfo) {
+	b.UnmakeMove(u)
+	if t.nn != nil {
+		t.nn.pop()
+	}
+}
+
+// moveSlot 把走法映射到历史表下标；用最大半径编号，与棋盘半径无关
+func moveSlot(m Move) int {
+	g := geoms[maxBoardRadius]
+	return g.indexOf(m.From)*maxCells + g.indexOf(m.To)
+}
+
+// recordCutoff 记下引起截断的走法：更新本层杀手着，并按 depth² 累加历史分
+func (t *searchThread) recordCutoff(side CellState, ply, depth int, mv Move) {
+	if ply < maxPly && t.killers[ply][0] != mv {
+		t.killers[ply][1] = t.killers[ply][0]
+		t.killers[ply][0] = mv
+	}
+	h := &t.history[sideIdx(side)][moveSlot(mv)]
+	*h += int32(depth * depth)
+	if *h > historyMax {
+		for s := range t.history {
+			for i := range t.history[s] {
+				t.history[s][i] /= 2
+			}
+		}
+	}
+}
+
+// orderMoves 原地排序：TT 着 → 有感染的（感染多的、克隆优先）→ 杀手着 → 按历史分
+func (t *searchThread) orderMoves(b *Board, side CellState, ply int, moves []Move, ttMove Move, hasTT bool) {
+	keys := make([]int, len(moves))
+	hist := &t.history[sideIdx(side)]
+	for i, mv := range moves {
+		n := previewInfectedCount(b, mv, side)
+		switch {
+		case hasTT && mv == ttMove:
+			keys[i] = ttMoveKey
+		case n > 0:
+			keys[i] = infectKey + n<<8
+			if mv.IsClone() {
+				keys[i] += 1 << 7
+			}
+		case ply < maxPly && mv == t.killers[ply][0]:
+			keys[i] = killerKey
+		case ply < maxPly && mv == t.killers[ply][1]:
+			keys[i] = killerKey - 1
+		default:
+			keys[i] = int(hist[moveSlot(mv)])
+		}
+	}
+	sort.Stable(movesByKey{moves, keys})
+}
+
+type movesByKey struct {
+	moves []Move
+	keys  []int
+}
+
+func (m movesByKey) Len() int           { return len(m.moves) }
+func (m movesByKey) Less(i, j int) bool { return m.keys[i] > m.keys[j] }
+func (m movesByKey) Swap(i, j int) {
+	m.moves[i], m.moves[j] = m.moves[j], m.moves[i]
+	m.keys[i], m.keys[j] = m.keys[j], m.keys[i]
+}
+
+// evalFor 返回 side 视角的叶子分，直接按行棋方评估。评估函数不一定对称（比如边缘分只算一方），
+// 但置换表的键不含根方、表又跨搜索（乃至经分析缓存跨进程）保留，叶子分只能取决于局面和行棋方，
+// 不能取决于这次是替谁搜的。有 NNUE 累加器时直接用它，省掉整盘重算第一层。
+func (e *Engine) evalFor(t *searchThread, b *Board, side CellState) int {
+	if t.nn != nil {
+		return t.nn.eval(side)
+	}
+	return e.eval(b, side)
+}
+
+// negamax 是 PVS 搜索核心，返回 side（当前行棋方）视角的分数。
+// 第一个走法全窗口搜，其余先用零窗口证明不如它，失败才全窗口重搜。
+// 双方规则对称：都剔除 0 感染跳跃（全被剔空时回退），跳跃都扣 jumpMovePenalty。
+// ply 为距根的层数，pv 输出本节点的主变例（不含走到本节点的那一步）。
+func (e *Engine) negamax(t *searchThread, b *Board, side CellState, depth, ply, alpha, beta int, pv *[]Move) int {
+	// 已被取消：直接返回，结果由上层丢弃
+	if e.aborted(t) {
+		return 0
+	}
+	e.nodes.Add(1)
+
+	// 节点键 = 棋盘哈希 ^ 行棋方（开局用规范局面，见 ttKey）
+	hash, sym := e.ttKey(b, side)
+
+	moves := GenerateMoves(b, side)
+	if len(moves) == 0 {
+		val := e.evalFor(t, b, side)
+		e.tt.store(hash, max(depth, 0), val, ttExact)
+		return val
+	}
+	if depth <= 0 {
+		// 名义深度用完：还有大感染可吃就接着做静态搜索，分数只在窗口内精确
+		val := e.quiesce(t, b, side, 0, alpha, beta, moves)
+		if e.aborted(t) {
+			return 0
+		}
+		flag := ttExact
+		switch {
+		case val <= alpha:
+			flag = ttUpper
+		case val >= beta:
+			flag = ttLower
+		}
+		e.tt.store(hash, 0, val, flag)
+		return val
+	}
+
+	if hit, val, flag := e.tt.probe(hash, depth); hit {
+		switch flag {
+		case ttExact:
+			return val
+		case ttLower:
+			alpha = max(alpha, val)
+		case ttUpper:
+			beta = min(beta, val)
+		}
+		if alpha >= beta {
+			return val
+		}
+	}
+	alphaOrig := alpha
+
+	moves = filterZeroInfectJumpsOrFallback(b, side, moves)
+	ttMove, hasTT := e.tt.probeMove(hash)
+	ttMove = sym.Inverse().ApplyMove(ttMove)
+	t.orderMoves(b, side, ply, moves, ttMove, hasTT)
+
+	next := Opponent(side)
+	best := -searchInf
+	var bestMove Move
+	for i, mv := range moves {
+		// 跳跃罚分：子节点窗口整体平移 pen，搜完再扣，零窗口判断依然精确
+		pen := 0
+		if mv.IsJump() && !e.learned {
+			pen = jumpMovePenalty
+		}
+		a, bt := alpha+pen, beta+pen
+
+		undo := t.makeMove(b, mv, side)
+		var line []Move
+		var score int
+		if i == 0 {
+			score = -e.negamax(t, b, next, depth-1, ply+1, -bt, -a, &line)
+		} else {
+			score = -e.negamax(t, b, next, depth-1, ply+1, -a-1, -a, &line)
+			if score > a && score < bt {
+				line = line[:0]
+				score = -e.negamax(t, b, next, depth-1, ply+1, -bt, -a, &line)
+			}
+		}
+		t.unmakeMove(b, undo)
+		score -= pen
+
+		if score > best {
+			best, bestMove = score, mv
+			*pv = append(append((*pv)[:0], mv), line...)
+		}
+		if score > alpha {
+			alpha = score
+			if alpha >= beta {
+				t.recordCutoff(side, ply, depth, mv)
+				break
+			}
+		}
+	}
+
+	// 子树中途被取消：分数不可信，不能写进置换表
+	if e.aborted(t) {
+		return 0
+	}
+
+	var flag ttFlag
+	switch {
+	case best <= alphaOrig:
+		flag = ttUpper
+	case best >= beta:
+		flag = ttLower
+	default:
+		flag = ttExact
+	}
+	e.tt.store(hash, depth, best, flag)
+	e.tt.storeMove(hash, sym.ApplyMove(bestMove))
+	return best
+}
+
+const (
+	// defaultQuiesceFlips 静态搜索只看感染至少这么多子的走法
+	defaultQuiesceFlips = 3
+	// quiesceMaxPly 静态搜索最多在名义深度之外再走几层
+	quiesceMaxPly = 4
+	// quiesceFlipGain delta 剪枝里每感染一子估计的最大涨分（静态评估的刻度）：
+	// 对方少一子、我方多一子是 2×Piece，再给感染势能项留些余量
+	quiesceFlipGain = 15
+)
+
+func (e *Engine) quiesceFlips() int {
+	if e.opts.QuiesceFlips == 0 {
+		return defaultQuiesceFlips
+	}
+	return e.opts.QuiesceFlips
+}
+
+// quiesceDelta delta 剪枝用的每子涨分；0 表示不剪。
+// 学出来的评估刻度和静态评估不同，不给 QuiesceDelta 时不剪
+func (e *Engine) quiesceDelta() int {
+	switch d := e.opts.QuiesceDelta; {
+	case d < 0:
+		return 0
+	case d > 0:
+		return d
+	case e.learned:
+		return 0
+	}
+	return quiesceFlipGain
+}
+
+// quiesce 静态搜索，返回 side 视角的分数（fail-soft）。
+// 行棋方可以不走大感染而停在当前局面（stand-pat），所以分数不低于静态评估；
+// 只展开感染 ≥ quiesceFlips 的走法，感染最多的先走。
+// delta 剪枝：静态分加上这步能涨的上限仍不到 α 的走法不搜（后面感染更少的也不搜）。
+// moves 为本局面全部走法，调用方已经生成过，不能为空。
+func (e *Engine) quiesce(t *searchThread, b *Board, side CellState, qply, alpha, beta int, moves []Move) int {
+	stand := e.evalFor(t, b, side)
+	flips := e.quiesceFlips()
+	if flips < 0 || qply >= quiesceMaxPly || stand >= beta {
+		return stand
+	}
+	alpha = max(alpha, stand)
+
+	var caps []Move
+	var keys []int
+	for _, mv := range moves {
+		if n := previewInfectedCount(b, mv, side); n >= flips {
+			k := n << 1
+			if mv.IsClone() {
+				k++
+			}
+			caps = append(caps, mv)
+			keys = append(keys, k)
+		}
+	}
+	sort.Stable(movesByKey{caps, keys})
+
+	delta := e.quiesceDelta()
+	next := Opponent(side)
+	best := stand
+	for i, mv := range caps {
+		pen := 0
+		if mv.IsJump() && !e.learned {
+			pen = jumpMovePenalty
+		}
+		// keys[i]>>1 是感染数，多算一子给落点那一格和势能项；不扣跳跃罚分，上限只随感染数变。
+		// caps 按感染数从多到少排，这一步够不着 α，后面的也够不着；
+		// fail-soft 的返回值要取这个上限，否则父节点拿到的界偏紧
+		if bound := stand + (keys[i]>>1+1)*delta; delta > 0 && bound <= alpha {
+			best = max(best, bound)
+			break
+		}
+		if e.aborted(t) {
+			return 0
+		}
+		e.nodes.Add(1)
+		undo := t.makeMove(b, mv, side)
+		var score int
+		if replies := GenerateMoves(b, next); len(replies) == 0 {
+			score = -e.evalFor(t, b, next)
+		} else {
+			score = -e.quiesce(t, b, next, qply+1, -beta-pen, -alpha-pen, replies)
+		}
+		t.unmakeMove(b, undo)
+		score -= pen
+
+		if score > best {
+			best = score
+		}
+		if score > alpha {
+			alpha = score
+			if alpha >= beta {
+				break
+			}
+		}
+	}
+	return best
+}
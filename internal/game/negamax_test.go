@@ -0,0 +1,180 @@
+package game
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// materialEval 只数子差，与走法路径无关，便于和暴力搜索逐分比较
+func materialEval(b *Board, p CellState) int {
+	return b.CountPieces(p) - b.CountPieces(Opponent(p))
+}
+
+// bruteNegamax 不剪枝、不用置换表的参考实现，走法规则与 negamax 相同；quiesce 时叶子接暴力静态搜索
+func bruteNegamax(b *Board, side CellState, depth int, quiesce bool) int {
+	moves := GenerateMoves(b, side)
+	if len(moves) == 0 || (depth == 0 && !quiesce) {
+		return materialEval(b, side)
+	}
+	if depth == 0 {
+		return bruteQuiesce(b, side, 0, moves)
+	}
+	best := -searchInf
+	for _, mv := range filterZeroInfectJumpsOrFallback(b, side, moves) {
+		undo := mMakeMoveWithUndo(b, mv, side)
+		score := -bruteNegamax(b, Opponent(side), depth-1, quiesce)
+		b.UnmakeMove(undo)
+		if mv.IsJump() {
+			score -= jumpMovePenalty
+		}
+		best = max(best, score)
+	}
+	return best
+}
+
+// bruteQuiesce 静态搜索的参考实现：可以停手，也可以走任一步感染 ≥ defaultQuiesceFlips 的
+func bruteQuiesce(b *Board, side CellState, qply int, moves []Move) int {
+	best := materialEval(b, side)
+	if qply >= quiesceMaxPly {
+		return best
+	}
+	for _, mv := range moves {
+		if previewInfectedCount(b, mv, side) < defaultQuiesceFlips {
+			continue
+		}
+		undo := mMakeMoveWithUndo(b, mv, side)
+		var score int
+		if replies := GenerateMoves(b, Opponent(side)); len(replies) == 0 {
+			score = -materialEval(b, Opponent(side))
+		} else {
+			score = -bruteQuiesce(b, Opponent(side), qply+1, replies)
+		}
+		b.UnmakeMove(undo)
+		if mv.IsJump() {
+			score -= jumpMovePenalty
+		}
+		best = max(best, score)
+	}
+	return best
+}
+
+// TestNegamaxMatchesBruteForce PVS + 置换表 + 走法排序 + delta 剪枝只影响速度，不应改变固定深度的分数
+func TestNegamaxMatchesBruteForce(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	gs := NewGameState(3)
+	for ply := 0; ply < 24 && !gs.GameOver; ply++ {
+		if ply%4 == 0 {
+			side := gs.CurrentPlayer
+			for _, c := range []struct {
+				depth   int
+				quiesce bool
+			}{{3, false}, {3, true}} {
+				opts := EngineOptions{TTEntries: 1 << 14, Eval: materialEval, QuiesceFlips: -1}
+				if c.quiesce {
+					opts.QuiesceFlips = 0
+				}
+				got := NewEngine(opts).DeepSearch(gs.Board, 0, side, c.depth)
+				want := bruteNegamax(gs.Board, side, c.depth, c.quiesce)
+				if got != want {
+					t.Fatalf("ply %d 深度 %d 静态搜索 %v: negamax %d，暴力 %d", ply, c.depth, c.quiesce, got, want)
+				}
+			}
+		}
+		moves := GenerateMoves(gs.Board, gs.CurrentPlayer)
+		if _, _, err := gs.MakeMove(moves[r.Intn(len(moves))]); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// TestQuiescenceMatchesBruteForce 半径 4 的中盘常有大感染可吃：静态搜索应改变叶子分数，且与暴力静态搜索逐分一致
+func TestQuiescenceMatchesBruteForce(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	gs := NewGameState(4)
+	changed := 0
+	for ply := 0; ply <= 36 && !gs.GameOver; ply++ {
+		if ply >= 24 && ply%4 == 0 {
+			side := gs.CurrentPlayer
+			for depth := 1; depth <= 2; depth++ {
+				e := NewEngine(EngineOptions{TTEntries: 1 << 14, Eval: materialEval})
+				got := e.DeepSearch(gs.Board, 0, side, depth)
+				if want := bruteNegamax(gs.Board, side, depth, true); got != want {
+					t.Fatalf("ply %d 深度 %d: negamax %d，暴力 %d", ply, depth, got, want)
+				}
+				if got != bruteNegamax(gs.Board, side, depth, false) {
+					changed++
+				}
+			}
+		}
+		moves := GenerateMoves(gs.Board, gs.CurrentPlayer)
+		if _, _, err := gs.MakeMove(moves[r.Intn(len(moves))]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if changed == 0 {
+		t.Fatal("测试局面里静态搜索一次都没改变分数，换个种子")
+	}
+}
+
+// TestQuiesceDeltaPruning 子数差评估下每感染一子至多涨 2 分（克隆再 +1），QuiesceDelta=2 是够紧的上限：
+// 剪枝真的发生（节点更少），分数仍与暴力静态搜索一致
+func TestQuiesceDeltaPruning(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	gs := NewGameState(4)
+	var pruned, full uint64
+	for ply := 0; ply <= 36 && !gs.GameOver; ply++ {
+		if ply >= 24 && ply%4 == 0 {
+			side := gs.CurrentPlayer
+			want := bruteNegamax(gs.Board, side, 2, true)
+			for _, delta := range []int{2, -1} {
+				e := NewEngine(EngineOptions{TTEntries: 1 << 14, Eval: materialEval, QuiesceDelta: delta})
+				if got := e.DeepSearch(gs.Board, 0, side, 2); got != want {
+					t.Fatalf("ply %d QuiesceDelta=%d: negamax %d，暴力 %d", ply, delta, got, want)
+				}
+				if delta > 0 {
+					pruned += e.nodes.Load()
+				} else {
+					full += e.nodes.Load()
+				}
+			}
+			checkQuiesceWindows(t, gs.Board, side)
+		}
+		moves := GenerateMoves(gs.Board, gs.CurrentPlayer)
+		if _, _, err := gs.MakeMove(moves[r.Intn(len(moves))]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if pruned >= full {
+		t.Fatalf("delta 剪枝没有省下节点: %d vs 不剪 %d", pruned, full)
+	}
+}
+
+// checkQuiesceWindows 对 b 及其每个子局面，用不同窗口直接调 quiesce：
+// 窗口内的结果要精确，fail-low 要是真值的上界，fail-high 要是下界
+func checkQuiesceWindows(t *testing.T, b *Board, side CellState) {
+	t.Helper()
+	e := NewEngine(EngineOptions{TTEntries: 1 << 10, Eval: materialEval, QuiesceDelta: 2})
+	check := func(b *Board, side CellState) {
+		moves := GenerateMoves(b, side)
+		if len(moves) == 0 {
+			return
+		}
+		want := bruteQuiesce(b, side, 0, moves)
+		for off := -8; off <= 8; off += 2 {
+			alpha, beta := want+off-1, want+off+1
+			got := e.quiesce(e.newThread(b), b, side, 0, alpha, beta, moves)
+			switch {
+			case got <= alpha && want > got,
+				got >= beta && want < got,
+				got > alpha && got < beta && got != want:
+				t.Fatalf("窗口 (%d,%d): quiesce %d，真值 %d", alpha, beta, got, want)
+			}
+		}
+	}
+	check(b, side)
+	for _, mv := range GenerateMoves(b, side) {
+		undo := mMakeMoveWithUndo(b, mv, side)
+		check(b, Opponent(side))
+		b.UnmakeMove(undo)
+	}
+}
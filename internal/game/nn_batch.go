@@ -0,0 +1,183 @@
+// internal/game/nn_batch.go
+package game
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// BatchModel 能一次推理多个局面的模型；结果与逐个调用 Predict 一致
+type BatchModel interface {
+	Model
+	PredictBatch(boards []*Board, sides []CellState) (policies [][]float32, values []float32, err error)
+}
+
+const (
+	defaultMaxBatch    = 32
+	defaultBatchWindow = 2 * time.Millisecond
+)
+
+// BatcherOptions 凑批参数；零值字段用默认值
+type BatcherOptions struct {
+	MaxBatch int           // 每批最多几个局面；<=0 用 defaultMaxBatch
+	Window   time.Duration // 第一个请求到达后最多再等多久凑批；<=0 用 defaultBatchWindow
+	Workers  int           // 同时在跑的批数，一般等于会话数；<=0 为 1
+}
+
+// BatchStats 累计的请求数与批数，平均批大小 = Requests / Batches
+type BatchStats struct {
+	Requests, Batches uint64
+}
+
+// Batcher 把多个搜索 goroutine 的 Predict 请求攒成一批，一次交给底层模型。
+// 本身也是 Model，可以直接 SetDefaultModel。底层不是 BatchModel 时每批内逐个并发 Predict。
+type Batcher struct {
+	m    Model
+	bm   BatchModel
+	opts BatcherOptions
+
+	mu     sync.RWMutex // 保护 closed；Predict 持读锁投递，Close 持写锁关通道
+	closed bool
+	reqs   chan *nnRequest
+	wg     sync.WaitGroup
+
+	requests, batches atomic.Uint64
+}
+
+type nnRequest struct {
+	b      *Board
+	side   CellState
+	policy []float32
+	value  float32
+	err    error
+	done   chan struct{}
+}
+
+// ErrBatcherClosed Close 之后再调用 Predict 返回的错误
+var ErrBatcherClosed = errors.New("batcher 已关闭")
+
+// NewBatcher 包装 m 并启动 opts.Workers 个凑批 goroutine；用完要 Close（会一并关闭 m）
+func NewBatcher(m Model, opts BatcherOptions) *Batcher {
+	if opts.MaxBatch <= 0 {
+		opts.MaxBatch = defaultMaxBatch
+	}
+	if opts.Window <= 0 {
+		opts.Window = defaultBatchWindow
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = 1
+	}
+	bt := &Batcher{m: m, opts: opts, reqs: make(chan *nnRequest, opts.MaxBatch*opts.Workers)}
+	bt.bm, _ = m.(BatchModel)
+	for i := 0; i < opts.Workers; i++ {
+		bt.wg.Add(1)
+		go bt.loop()
+	}
+	return bt
+}
+
+// Predict 投递请求并等结果。b 在返回前不能被改动（搜索线程本来就在等结果，天然满足）
+func (bt *Batcher) Predict(b *Board, side CellState) ([]float32, float32, error) {
+	req := &nnRequest{b: b, side: side, done: make(chan struct{})}
+	bt.mu.RLock()
+	if bt.closed {
+		bt.mu.RUnlock()
+		return nil, 0, ErrBatcherClosed
+	}
+	bt.reqs <- req
+	bt.mu.RUnlock()
+	<-req.done
+	return req.policy, req.value, req.err
+}
+
+func (bt *Batcher) Info() ModelInfo { return bt.m.Info() }
+
+// Close 等已投递的请求跑完，再关闭底层模型
+func (bt *Batcher) Close() error {
+	bt.mu.Lock()
+	if bt.closed {
+		bt.mu.Unlock()
+		return nil
+	}
+	bt.closed = true
+	close(bt.reqs)
+	bt.mu.Unlock()
+	bt.wg.Wait()
+	return bt.m.Close()
+}
+
+// Stats 返回目前为止的请求数和批数
+func (bt *Batcher) Stats() BatchStats {
+	return BatchStats{Requests: bt.requests.Load(), Batches: bt.batches.Load()}
+}
+
+// loop 拿到第一个请求后在 Window 内尽量凑满 MaxBatch，然后跑一批
+func (bt *Batcher) loop() {
+	defer bt.wg.Done()
+	batch := make([]*nnRequest, 0, bt.opts.MaxBatch)
+	timer := time.NewTimer(0)
+	<-timer.C
+	for first := range bt.reqs {
+		batch = append(batch[:0], first)
+		timer.Reset(bt.opts.Window)
+	fill:
+		for len(batch) < bt.opts.MaxBatch {
+			select {
+			case r, ok := <-bt.reqs:
+				if !ok {
+					break fill
+				}
+				batch = append(batch, r)
+			case <-timer.C:
+				break fill
+			}
+		}
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		bt.run(batch)
+	}
+}
+
+func (bt *Batcher) run(batch []*nnRequest) {
+	bt.requests.Add(uint64(len(batch)))
+	bt.batches.Add(1)
+	defer func() {
+		for _, r := range batch {
+			close(r.done)
+		}
+	}()
+
+	if bt.bm == nil {
+		// 底层只会单个推理（比如纯 Go 后端），批内并发跑
+		var wg sync.WaitGroup
+		for _, r := range batch {
+			wg.Add(1)
+			go func(r *nnRequest) {
+				defer wg.Done()
+				r.policy, r.value, r.err = bt.m.Predict(r.b, r.side)
+			}(r)
+		}
+		wg.Wait()
+		return
+	}
+
+	boards := make([]*Board, len(batch))
+	sides := make([]CellState, len(batch))
+	for i, r := range batch {
+		boards[i], sides[i] = r.b, r.side
+	}
+	policies, values, err := bt.bm.PredictBatch(boards, sides)
+	for i, r := range batch {
+		if err != nil {
+			r.err = err
+			continue
+		}
+		r.policy, r.value = policies[i], values[i]
+	}
+}
@@ -0,0 +1,83 @@
+package game
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countModel 假模型：value = side 方子数 / 100，policy[0] 记 side；记下每批大小
+type countModel struct {
+	calls  atomic.Int32
+	maxLen atomic.Int32
+}
+
+func (m *countModel) Predict(b *Board, side CellState) ([]float32, float32, error) {
+	p := make([]float32, PolicyLen)
+	p[0] = float32(side)
+	return p, float32(b.CountPieces(side)) / 100, nil
+}
+
+func (m *countModel) PredictBatch(boards []*Board, sides []CellState) ([][]float32, []float32, error) {
+	m.calls.Add(1)
+	if n := int32(len(boards)); n > m.maxLen.Load() {
+		m.maxLen.Store(n)
+	}
+	ps := make([][]float32, len(boards))
+	vs := make([]float32, len(boards))
+	for i, b := range boards {
+		ps[i], vs[i], _ = m.Predict(b, sides[i])
+	}
+	return ps, vs, nil
+}
+
+func (m *countModel) Info() ModelInfo { return ModelInfo{Backend: "fake"} }
+func (m *countModel) Close() error    { return nil }
+
+// TestBatcherCoalesces 16 个 goroutine 同时请求，结果各归各位，而且确实凑成了批
+func TestBatcherCoalesces(t *testing.T) {
+	fake := &countModel{}
+	bt := NewBatcher(fake, BatcherOptions{MaxBatch: 8, Window: 50 * time.Millisecond})
+
+	const n = 16
+	var wg sync.WaitGroup
+	errs := make(chan string, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			b := NewBoard(4)
+			// 每个请求的 A 方子数不同，用来核对结果没串
+			for _, c := range b.AllCoords()[:i+1] {
+				_ = b.Set(c, PlayerA)
+			}
+			side := []CellState{PlayerA, PlayerB}[i%2]
+			p, v, err := bt.Predict(b, side)
+			want := float32(b.CountPieces(side)) / 100
+			if err != nil || v != want || p[0] != float32(side) {
+				errs <- "结果串了"
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for e := range errs {
+		t.Fatal(e)
+	}
+
+	st := bt.Stats()
+	if st.Requests != n || st.Batches >= n || fake.maxLen.Load() < 2 {
+		t.Fatalf("没有凑批: %+v，最大批 %d", st, fake.maxLen.Load())
+	}
+	if fake.maxLen.Load() > 8 {
+		t.Fatalf("批大小 %d 超过 MaxBatch", fake.maxLen.Load())
+	}
+
+	if err := bt.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := bt.Predict(NewBoard(4), PlayerA); err != ErrBatcherClosed {
+		t.Fatalf("关闭后应返回 ErrBatcherClosed，得到 %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+// internal/game/nnue_eval.go
+package game
+
+import "hexxagon_go/internal/nnue"
+
+// NNUE 输入特征：从某一方（视角方）看，每个格子 × {我方子, 对方子, 障碍} 一个 0/1 特征，空格不占特征。
+// 格子用最大半径下的编号，小棋盘也能用同一张网。
+// 每个局面同时维护两个视角的累加器，评估谁就用谁的。
+const (
+	NNUEPlanes = 3
+	NNUEInputs = NNUEPlanes * maxCells // 183
+)
+
+// NNUEFeature 返回 cell（最大半径编号）上的 s 在视角 persp 下的特征号；空格返回 -1
+func NNUEFeature(cell int, s CellState, persp CellState) int {
+	switch s {
+	case persp:
+		return cell
+	case Opponent(persp):
+		return maxCells + cell
+	case Blocked:
+		return 2*maxCells + cell
+	}
+	return -1
+}
+
+// NNUEFeatures 列出 b 在视角 persp 下所有激活的特征
+func NNUEFeatures(b *Board, persp CellState) []int {
+	g := b.geom
+	out := make([]int, 0, len(g.coords))
+	for i := range g.coords {
+		if f := NNUEFeature(g.zidx[i], b.cellAt(i), persp); f >= 0 {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// NNUEEval 包装成 EvalFunc：每次从头算累加器，给根排序这类零散调用用；
+// 搜索树里走 searchThread 的增量累加器（见 NNUEEvaluator）
+func NNUEEval(net *nnue.Net) EvalFunc {
+	return nnueEval[nnue.Accumulator](net)
+}
+
+// QNNUEEval 同 NNUEEval，用量化网
+func QNNUEEval(net *nnue.QNet) EvalFunc {
+	return nnueEval[nnue.QAccumulator](net)
+}
+
+// nnueNet 浮点网和量化网共有的累加器操作，A 是各自的累加器类型
+type nnueNet[A any] interface {
+	Refresh(acc *A, features []int)
+	AddFeature(acc *A, f int)
+	SubFeature(acc *A, f int)
+	Forward(acc *A) float32
+}
+
+func nnueEval[A any](net nnueNet[A]) EvalFunc {
+	return func(b *Board, player CellState) int {
+		var acc A
+		net.Refresh(&acc, NNUEFeatures(b, player))
+		return int(net.Forward(&acc))
+	}
+}
+
+// accStack 搜索线程里的增量累加器，浮点/量化两种实现
+type accStack interface {
+	push(b *Board, u undoInfo)
+	pop()
+	eval(player CellState) int
+}
+
+// nnueStack 搜索线程的累加器栈：每走一步压一层、撤一步弹一层。
+// 每层存两个视角（下标 sideIdx），只按 undoInfo 里变了的格子加减特征列。
+type nnueStack[A any, N nnueNet[A]] struct {
+	net N
+	acc [][2]A
+}
+
+func newNNUEStack[A any, N nnueNet[A]](net N, b *Board) *nnueStack[A, N] {
+	s := &nnueStack[A, N]{net: net, acc: make([][2]A, 1, maxPly+1)}
+	for _, p := range []CellState{PlayerA, PlayerB} {
+		net.Refresh(&s.acc[0][sideIdx(p)], NNUEFeatures(b, p))
+	}
+	return s
+}
+
+// push 在 b 已经走完、u 为这一步的 undo 时调用
+func (s *nnueStack[A, N]) push(b *Board, u undoInfo) {
+	s.acc = append(s.acc, s.acc[len(s.acc)-1])
+	top := &s.acc[len(s.acc)-1]
+	g := b.geom
+	for _, c := range u.changed {
+		i := g.indexOf(c.coord)
+		cell, now := g.zidx[i], b.cellAt(i)
+		for _, p := range []CellState{PlayerA, PlayerB} {
+			acc := &top[sideIdx(p)]
+			if f := NNUEFeature(cell, c.prev, p); f >= 0 {
+				s.net.SubFeature(acc, f)
+			}
+			if f := NNUEFeature(cell, now, p); f >= 0 {
+				s.net.AddFeature(acc, f)
+			}
+		}
+	}
+}
+
+func (s *nnueStack[A, N]) pop() {
+	s.acc = s.acc[:len(s.acc)-1]
+}
+
+// eval 当前局面 player 视角的分数
+func (s *nnueStack[A, N]) eval(player CellState) int {
+	return int(s.net.Forward(&s.acc[len(s.acc)-1][sideIdx(player)]))
+}
@@ -0,0 +1,100 @@
+package game
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"hexxagon_go/internal/nnue"
+)
+
+// TestNNUEIncrementalMatchesRefresh 随机走子、撤子若干步，增量累加器始终与从头计算一致
+func TestNNUEIncrementalMatchesRefresh(t *testing.T) {
+	net := nnue.Random(NNUEInputs, 7)
+	r := rand.New(rand.NewSource(7))
+	b := NewGameState(4).Board
+	s := newNNUEStack(net, b)
+	side := PlayerA
+
+	check := func(step int) {
+		for _, p := range []CellState{PlayerA, PlayerB} {
+			var want nnue.Accumulator
+			net.Refresh(&want, NNUEFeatures(b, p))
+			got := &s.acc[len(s.acc)-1][sideIdx(p)]
+			for i := range want.V {
+				if math.Abs(float64(got.V[i]-want.V[i])) > 1e-3 {
+					t.Fatalf("第 %d 步 %v 视角累加器[%d] = %v，重算 %v", step, p, i, got.V[i], want.V[i])
+				}
+			}
+		}
+	}
+
+	var undos []undoInfo
+	for step := 0; step < 40; step++ {
+		moves := GenerateMoves(b, side)
+		if len(moves) == 0 || (len(undos) > 0 && r.Intn(4) == 0) {
+			if len(undos) == 0 {
+				break
+			}
+			b.UnmakeMove(undos[len(undos)-1])
+			s.pop()
+			undos = undos[:len(undos)-1]
+		} else {
+			u := mMakeMoveWithUndo(b, moves[r.Intn(len(moves))], side)
+			s.push(b, u)
+			undos = append(undos, u)
+		}
+		side = Opponent(side)
+		check(step)
+	}
+}
+
+// TestEngineWithNNUE 配了 NNUE（浮点/量化）的引擎：深度 1 的分数等于逐个走法用 NNUE 从头评估的最大值，深搜也能正常跑完
+func TestEngineWithNNUE(t *testing.T) {
+	net := nnue.Random(NNUEInputs, 3)
+	qnet := nnue.Quantize(net)
+	r := rand.New(rand.NewSource(3))
+	gs := NewGameState(4)
+	for i := 0; i < 16; i++ {
+		moves := GenerateMoves(gs.Board, gs.CurrentPlayer)
+		gs.MakeMove(moves[r.Intn(len(moves))])
+	}
+	b, side := gs.Board, gs.CurrentPlayer
+
+	for _, tc := range []struct {
+		name string
+		opts EngineOptions
+		eval EvalFunc
+	}{
+		{"float", EngineOptions{Evaluator: &NNUEEvaluator{Net: net}}, NNUEEval(net)},
+		{"quantized", EngineOptions{Evaluator: &NNUEEvaluator{Net: net, QNet: qnet}}, QNNUEEval(qnet)},
+	} {
+		want := -searchInf
+		for _, mv := range filterZeroInfectJumpsOrFallback(b, side, GenerateMoves(b, side)) {
+			u := mMakeMoveWithUndo(b, mv, side)
+			want = max(want, -tc.eval(b, Opponent(side))) // 叶子按行棋方（对手）评估
+			b.UnmakeMove(u)
+		}
+		tc.opts.TTEntries, tc.opts.EndgameEmpties = 1<<12, -1
+		plain := tc.opts
+		plain.QuiesceFlips = -1 // 叶子不接静态搜索，深度 1 才等于逐个评估的最大值
+		if got := NewEngine(plain).DeepSearch(b, 0, side, 1); abs(got-want) > 1 {
+			t.Fatalf("%s: 深度 1 分数 %d，逐个评估的最大值 %d", tc.name, got, want)
+		}
+
+		// 深搜一遍（含静态搜索）：累加器栈在整棵树里推进、回退，结束时应回到根
+		e := NewEngine(tc.opts)
+		th := e.newThread(b)
+		e.negamax(th, b, side, 3, 0, -searchInf, searchInf, new([]Move))
+		var depth int
+		switch s := th.nn.(type) {
+		case *nnueStack[nnue.Accumulator, *nnue.Net]:
+			depth = len(s.acc)
+		case *nnueStack[nnue.QAccumulator, *nnue.QNet]:
+			depth = len(s.acc)
+		}
+		if depth != 1 {
+			t.Fatalf("%s: 搜索结束累加器栈深 %d，应为 1", tc.name, depth)
+		}
+	}
+}
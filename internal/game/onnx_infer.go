@@ -2,111 +2,307 @@
 package game
 
 import (
-	_ "embed"
-	//"errors"
+	"embed"
 	"fmt"
 	"os"
-	//"path/filepath"
 	"sync"
 
 	ort "github.com/yalue/onnxruntime_go"
 )
 
-// —— 把 ONNX 模型打进二进制 ——
+// —— 把模型打进二进制 ——
+// hex_cnn.onnx 给 onnxruntime 用；hex_cnn.bin（可选）给纯 Go 前向用，见 cnn.go
 //
-//go:embed assets/hex_cnn.onnx
-var onnxBytes []byte
+//go:embed assets/hex_cnn.*
+var modelFS embed.FS
+
+// I/O 名自动识别的候选：game 旧约定是 state/policy/value，导出脚本是 x/logits/value
+var (
+	onnxInputNames  = []string{"state", "x"}
+	onnxPolicyNames = []string{"policy", "logits"}
+	onnxValueNames  = []string{"value"}
+)
 
-// 如果你的导出脚本用了别的输入/输出名，请改这里：
-// 训练脚本里通常是 input_names=["state"], output_names=["policy","value"]
 const (
-	onnxInputName  = "state"
-	onnxPolicyName = "policy"
-	onnxValueName  = "value"
-	grid           = 9
-	radius         = 4
-	featPlanes     = 3 // [my, opp, mask]
-	policyOutDim   = 81
+	grid       = 9
+	radius     = 4
+	featPlanes = 3 // [my, opp, mask]
 )
 
 var (
-	ortOnce  sync.Once
-	ortErr   error
-	ortSess  *ort.AdvancedSession
-	ortMu    sync.Mutex // AdvancedSession 里绑定了固定的张量，这里串行化 Run，先稳妥跑通
-	inTensor *ort.Tensor[float32]
-	outP     *ort.Tensor[float32]
-	outV     *ort.Tensor[float32]
-	tmpModel string
+	ortOnce sync.Once
+	ortErr  error
 )
 
-// Lazy 初始化 ONNX Runtime & 会话
-func ensureONNX() error {
+// initORT 初始化 onnxruntime 全局环境（进程内一次）
+func initORT() error {
 	ortOnce.Do(func() {
-		// 1) 选择共享库路径（可删掉，改用环境变量 ONNXRUNTIME_SHARED_LIBRARY_PATH）
+		// 官方建议明确设置共享库路径，避免默认名找不到库
 		if p := os.Getenv("ONNXRUNTIME_SHARED_LIBRARY_PATH"); p != "" {
-			ort.SetSharedLibraryPath(p) // 官方建议明确设置路径，避免默认名找不到库
+			ort.SetSharedLibraryPath(p)
+		}
+		if ort.IsInitialized() {
+			return
 		}
 		if err := ort.InitializeEnvironment(); err != nil {
 			ortErr = fmt.Errorf("InitializeEnvironment: %w", err)
-			return
 		}
-		// 退出时不强制销毁，进程结束会释放；若你想显式释放，可暴露 ShutdownONNX()
-		// defer ort.DestroyEnvironment()
+	})
+	return ortErr
+}
+
+// onnxModel onnxruntime 后端。DynamicAdvancedSession 每次 Run 自带输入输出张量，可以并发调用；
+// 开多个会话（ModelOptions.Sessions）时每次 Run 从池里借一个，各会话的线程池互不抢占
+type onnxModel struct {
+	sessions  []*ort.DynamicAdvancedSession
+	pool      chan *ort.DynamicAdvancedSession
+	batchable bool // 输入的 batch 维是动态的，PredictBatch 能一次跑完
+	info      ModelInfo
+}
+
+func loadONNXModel(opts ModelOptions) (Model, error) {
+	if err := initORT(); err != nil {
+		return nil, err
+	}
+
+	// 1) 模型来源：字节 > 路径 > 环境变量 > 内嵌
+	data, path := opts.Data, opts.Path
+	if data == nil && path == "" {
+		path = os.Getenv(onnxPathEnv)
+	}
+	source := path
+	if data == nil && path == "" {
+		var err error
+		if data, err = modelFS.ReadFile("assets/hex_cnn.onnx"); err != nil {
+			return nil, err
+		}
+		source = "embedded"
+	}
+	if data != nil && source == "" {
+		source = "bytes"
+	}
+
+	// 2) 会话选项（可选 CUDA，失败就留在 CPU）
+	sessOpts, err := ort.NewSessionOptions()
+	if err != nil {
+		return nil, fmt.Errorf("NewSessionOptions: %w", err)
+	}
+	defer sessOpts.Destroy()
+	if opts.Threads > 0 {
+		_ = sessOpts.SetIntraOpNumThreads(opts.Threads)
+	}
+	if opts.CUDA || os.Getenv(onnxCUDAEnv) == "1" {
+		if cu, err := ort.NewCUDAProviderOptions(); err == nil {
+			_ = sessOpts.AppendExecutionProviderCUDA(cu)
+			_ = cu.Destroy()
+		}
+	}
+
+	// 3) 读 I/O 信息，定下名字并检查策略头维度
+	var ins, outs []ort.InputOutputInfo
+	if data != nil {
+		ins, outs, err = ort.GetInputOutputInfoWithONNXData(data)
+	} else {
+		ins, outs, err = ort.GetInputOutputInfoWithOptions(path, sessOpts)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取模型 I/O: %w", err)
+	}
+	in, err := pickIO(ins, opts.InputName, onnxInputNames, "输入")
+	if err != nil {
+		return nil, err
+	}
+	pol, err := pickIO(outs, opts.PolicyName, onnxPolicyNames, "策略输出")
+	if err != nil {
+		return nil, err
+	}
+	val, err := pickIO(outs, opts.ValueName, onnxValueNames, "价值输出")
+	if err != nil {
+		return nil, err
+	}
+	// 旧模型的策略头只有 81 个落点，和现在的走法编码对不上，直接报错
+	if d := pol.Dimensions; len(d) == 0 || d[len(d)-1] != PolicyLen {
+		return nil, fmt.Errorf("模型策略头维度 %v，需要 %d（落点×%d 方向），请重新训练导出", d, PolicyLen, MoveDirs)
+	}
 
-		// 2) 将内嵌模型写到临时文件
-		f, err := os.CreateTemp("", "hex_cnn_*.onnx")
+	// 4) 建会话池
+	m := &onnxModel{
+		batchable: len(in.Dimensions) > 0 && in.Dimensions[0] < 0,
+		info: ModelInfo{
+			Backend: "onnx", Source: source,
+			Input: in.Name, Policy: pol.Name, Value: val.Name,
+		},
+	}
+	names := []string{in.Name}
+	outNames := []string{pol.Name, val.Name}
+	n := max(opts.Sessions, 1)
+	m.pool = make(chan *ort.DynamicAdvancedSession, n)
+	for i := 0; i < n; i++ {
+		var sess *ort.DynamicAdvancedSession
+		if data != nil {
+			sess, err = ort.NewDynamicAdvancedSessionWithONNXData(data, names, outNames, sessOpts)
+		} else {
+			sess, err = ort.NewDynamicAdvancedSession(path, names, outNames, sessOpts)
+		}
 		if err != nil {
-			ortErr = err
-			return
+			_ = m.Close()
+			return nil, fmt.Errorf("NewDynamicAdvancedSession: %w", err)
 		}
-		tmpModel = f.Name()
-		if _, err := f.Write(onnxBytes); err != nil {
-			_ = f.Close()
-			ortErr = err
-			return
+		m.sessions = append(m.sessions, sess)
+		m.pool <- sess
+	}
+	m.readMetadata()
+	return m, nil
+}
+
+// pickIO 指定了名字就必须存在；没指定就按候选顺序找，只有一个时直接用它
+func pickIO(infos []ort.InputOutputInfo, want string, candidates []string, what string) (ort.InputOutputInfo, error) {
+	if want != "" {
+		candidates = []string{want}
+	}
+	for _, c := range candidates {
+		for _, io := range infos {
+			if io.Name == c {
+				return io, nil
+			}
 		}
-		_ = f.Close()
+	}
+	if want == "" && len(infos) == 1 {
+		return infos[0], nil
+	}
+	var have []string
+	for _, io := range infos {
+		have = append(have, io.Name)
+	}
+	return ort.InputOutputInfo{}, fmt.Errorf("模型里找不到%s %v（有 %v）", what, candidates, have)
+}
 
-		// 3) 准备 I/O 张量，并用 AdvancedSession 绑定（固定 I/O，Run 时复用缓冲）
-		var e error
-		inTensor, e = ort.NewTensor(ort.NewShape(1, featPlanes, grid, grid), make([]float32, featPlanes*grid*grid))
-		if e != nil {
-			ortErr = e
-			return
+// readMetadata 元数据只是展示用，读不到就算了
+func (m *onnxModel) readMetadata() {
+	md, err := m.sessions[0].GetModelMetadata()
+	if err != nil {
+		return
+	}
+	defer md.Destroy()
+	m.info.Producer, _ = md.GetProducerName()
+	m.info.Graph, _ = md.GetGraphName()
+	m.info.Description, _ = md.GetDescription()
+	m.info.Version, _ = md.GetVersion()
+	if keys, err := md.GetCustomMetadataMapKeys(); err == nil && len(keys) > 0 {
+		m.info.Custom = make(map[string]string, len(keys))
+		for _, k := range keys {
+			if v, ok, _ := md.LookupCustomMetadataMap(k); ok {
+				m.info.Custom[k] = v
+			}
 		}
-		outP, e = ort.NewEmptyTensor[float32](ort.NewShape(1, policyOutDim))
-		if e != nil {
-			ortErr = e
-			return
+	}
+}
+
+func (m *onnxModel) Predict(b *Board, side CellState) ([]float32, float32, error) {
+	ps, vs, err := m.run([]*Board{b}, []CellState{side})
+	if err != nil {
+		return nil, 0, err
+	}
+	return ps[0], vs[0], nil
+}
+
+// PredictBatch 输入 batch 维是动态的就一次 Run 跑完，否则借会话逐个跑
+func (m *onnxModel) PredictBatch(boards []*Board, sides []CellState) ([][]float32, []float32, error) {
+	if m.batchable || len(boards) <= 1 {
+		return m.run(boards, sides)
+	}
+	ps := make([][]float32, len(boards))
+	vs := make([]float32, len(boards))
+	errs := make([]error, len(boards))
+	var wg sync.WaitGroup
+	for i := range boards {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			p, v, err := m.run(boards[i:i+1], sides[i:i+1])
+			if err == nil {
+				ps[i], vs[i] = p[0], v[0]
+			}
+			errs[i] = err
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, nil, err
 		}
-		outV, e = ort.NewEmptyTensor[float32](ort.NewShape(1, 1))
-		if e != nil {
-			ortErr = e
-			return
+	}
+	return ps, vs, nil
+}
+
+// run 把 len(boards) 个局面编成 (N,3,9,9) 跑一次
+func (m *onnxModel) run(boards []*Board, sides []CellState) ([][]float32, []float32, error) {
+	n := len(boards)
+	const plane = featPlanes * grid * grid
+	x := make([]float32, n*plane)
+	for i, b := range boards {
+		encodeBoard(b, sides[i], x[i*plane:(i+1)*plane])
+	}
+	in, err := ort.NewTensor(ort.NewShape(int64(n), featPlanes, grid, grid), x)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer in.Destroy()
+
+	// 输出传 nil 由 onnxruntime 分配，用完要 Destroy
+	outs := []ort.Value{nil, nil}
+	sess := <-m.pool
+	err = sess.Run([]ort.Value{in}, outs)
+	m.pool <- sess
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		for _, o := range outs {
+			if o != nil {
+				_ = o.Destroy()
+			}
 		}
+	}()
+	p, ok1 := outs[0].(*ort.Tensor[float32])
+	v, ok2 := outs[1].(*ort.Tensor[float32])
+	if !ok1 || !ok2 || len(p.GetData()) < n*PolicyLen || len(v.GetData()) < n {
+		return nil, nil, fmt.Errorf("模型输出类型或长度不对")
+	}
+	pd, vd := p.GetData(), v.GetData()
+	policies := make([][]float32, n)
+	values := make([]float32, n)
+	for i := range policies {
+		policies[i] = append([]float32(nil), pd[i*PolicyLen:(i+1)*PolicyLen]...)
+		values[i] = vd[i]
+	}
+	return policies, values, nil
+}
 
-		ortSess, e = ort.NewAdvancedSession(tmpModel,
-			[]string{onnxInputName}, []string{onnxPolicyName, onnxValueName},
-			[]ort.Value{inTensor}, []ort.Value{outP, outV}, nil)
-		if e != nil {
-			ortErr = e
-			return
+func (m *onnxModel) Info() ModelInfo { return m.info }
+
+func (m *onnxModel) Close() error {
+	var first error
+	for _, s := range m.sessions {
+		if err := s.Destroy(); err != nil && first == nil {
+			first = err
 		}
-	})
-	return ortErr
+	}
+	m.sessions = nil
+	return first
 }
 
-// 可选：在程序退出时调用，清理临时文件与环境
+// 可选：在程序退出时调用，释放全局模型与 onnxruntime 环境
 func ShutdownONNX() {
-	if tmpModel != "" {
-		_ = os.Remove(tmpModel)
+	defaultModelMu.Lock()
+	if defaultModel != nil {
+		_ = defaultModel.Close()
 	}
-	if ortSess != nil {
-		ortSess.Destroy()
-		ortSess = nil
+	defaultModel, defaultModelErr, defaultModelDone = nil, nil, false
+	defaultModelMu.Unlock()
+	if ort.IsInitialized() {
+		_ = ort.DestroyEnvironment()
 	}
-	ort.DestroyEnvironment()
 }
 
 // 计算 (q,r) 是否在半径为 4 的六边形棋盘内
@@ -141,61 +337,14 @@ func encodeBoard(b *Board, me CellState, dst []float32) {
 	}
 }
 
-// 只取 value 头做静态评估（返回 int，方便接到你的评分框架）
-func EvaluateNN(b *Board, me CellState) int {
-	if err := ensureONNX(); err != nil {
-		// 回退到旧静态评估也行：
-		// return evaluateStatic(b, me)
-		return 0
-	}
-	// 填充输入
-	data := inTensor.GetData()
-	encodeBoard(b, me, data)
-
-	// 跑一次
-	ortMu.Lock()
-	err := ortSess.Run()
-	ortMu.Unlock()
-	if err != nil {
-		return 0
-	}
-	// 读取 value，范围(-1,1)，放大到可比较的整数
-	v := outV.GetData()[0]
-	return int(v * 100.0)
-}
-
-// 可选：拿策略头（81 logits，自己在 Go 侧做 mask/softmax/挑选）
-func PolicyNN(b *Board, me CellState) ([]float32, error) {
-	if err := ensureONNX(); err != nil {
-		return nil, err
-	}
-	// 输入
-	data := inTensor.GetData()
-	encodeBoard(b, me, data)
-
-	ortMu.Lock()
-	err := ortSess.Run()
-	ortMu.Unlock()
-	if err != nil {
-		return nil, err
-	}
-	logits := make([]float32, policyOutDim)
-	copy(logits, outP.GetData())
-	// 这里不做 softmax；若需要概率，再减去 max 然后做 exp/sum
-	return logits, nil
-}
-
 // —— 小工具 ——
-// 直接给 policy 向量打非法格 -Inf
+// 直接给 policy 向量打 -Inf：落点或起点在棋盘外的走法
 func MaskPolicyInPlace(p []float32) {
 	const negInf = -1.0e30
-	i := 0
-	for r := -radius; r <= radius; r++ {
-		for q := -radius; q <= radius; q++ {
-			if !inBounds(q, r) {
-				p[i] = negInf
-			}
-			i++
+	for i := range p {
+		m := PolicyIndexToMove(i)
+		if !inBounds(m.To.Q, m.To.R) || !inBounds(m.From.Q, m.From.R) {
+			p[i] = negInf
 		}
 	}
 }
@@ -17,19 +17,13 @@ var (
 	policyAlsoOrder = true
 )
 
-// 9x9 平面 index （不引入 ml 包，避免 import cycle）
-func toIndex9(b *Board, c HexCoord) int {
-	grid := 2*b.radius + 1 // radius=4 -> grid=9
-	return (c.R+b.radius)*grid + (c.Q + b.radius)
-}
-
 // 根节点：用 CNN policy 砍掉尾部走法
 func policyPruneRoot(b *Board, player CellState, moves []Move) []Move {
 	if !policyPruneEnabled || len(moves) <= policyMinKeep {
 		return moves
 	}
 
-	logits, err := PolicyNN(b, player) // []float32, 长度 81
+	logits, err := PolicyNN(b, player) // []float32, 长度 PolicyLen
 	if err != nil || len(logits) == 0 {
 		return moves // 推理失败就不动
 	}
@@ -40,7 +34,7 @@ func policyPruneRoot(b *Board, player CellState, moves []Move) []Move {
 	}
 	arr := make([]scored, 0, len(moves))
 	for _, m := range moves {
-		idx := toIndex9(b, m.To)
+		idx := MoveToPolicyIndex(m)
 		// 保险：越界/异常就给极小值
 		var p float32 = -1e30
 		if idx >= 0 && idx < len(logits) {
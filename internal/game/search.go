@@ -0,0 +1,210 @@
+// internal/game/search.go
+package game
+
+import (
+	"context"
+	"time"
+)
+
+// defaultMovesToGo 剩余步数未知时，按还要走这么多步来分配剩余时间
+const defaultMovesToGo = 30
+
+// SearchLimits 约束一次迭代加深搜索；零值字段表示不限制
+type SearchLimits struct {
+	MaxDepth int // 最大名义深度；<=0 视为 1
+
+	MoveTime  time.Duration // 固定每步用时；>0 时忽略下面的时钟
+	Remaining time.Duration // 本方剩余总时间
+	Increment time.Duration // 每步加秒
+	MovesToGo int           // 距下次加时还要走几步；0 按 defaultMovesToGo 估算
+}
+
+// Budget 计算本步可用时间；返回 0 表示不限时
+func (l SearchLimits) Budget() time.Duration {
+	if l.MoveTime > 0 {
+		return l.MoveTime
+	}
+	if l.Remaining <= 0 {
+		return 0
+	}
+	mtg := l.MovesToGo
+	if mtg <= 0 {
+		mtg = defaultMovesToGo
+	}
+	budget := l.Remaining/time.Duration(mtg) + l.Increment*3/4
+	// 留一点余量，避免在最后一步超时
+	if limit := l.Remaining * 4 / 5; budget > limit {
+		budget = limit
+	}
+	return budget
+}
+
+// Searcher 是能在给定局面上选出一步的 AI：α-β 的 Engine 与 MCTS 都实现它，
+// UI 和自对弈只依赖这个接口，可以任选一种。
+type Searcher interface {
+	Search(ctx context.Context, root *Board, player CellState, limits SearchLimits) SearchResult
+}
+
+var (
+	_ Searcher = (*Engine)(nil)
+	_ Searcher = (*MCTS)(nil)
+)
+
+// SearchResult 迭代加深的结果，来自最后一层完整搜完的深度
+type SearchResult struct {
+	Move  Move
+	Score int           // 根节点分数（player 视角）
+	Depth int           // 最后完整完成的名义深度
+	Nodes uint64        // 全部迭代累计的 α-β 节点数
+	PV    []Move        // 主变例，PV[0] == Move，双方交替
+	Time  time.Duration // 实际用时
+	OK    bool          // false 表示无合法走法
+
+	Solved bool // 残局求解器给出的精确结果；此时 Score 为终局子数差，Depth 为 PV 长度
+	Book   bool // 开局库给出的走法，没有搜索；Score、Depth 为 0
+}
+
+// Search 迭代加深搜索，受 ctx 与 limits 的时间预算双重约束。
+// 第 1 层总是完整搜完，保证只要有合法走法就能返回一步；
+// 之后一旦取消或超时，正在进行的那一层被丢弃，返回上一层的结果。
+// 配了开局库且命中时直接返回库里的着；
+// 空格数进入残局阈值时先用至多一半预算试 SolveEndgame，解出来就直接返回。
+func (e *Engine) Search(ctx context.Context, root *Board, player CellState, limits SearchLimits) SearchResult {
+	if mv, ok := e.probeBook(root, player); ok {
+		return SearchResult{Move: mv, PV: []Move{mv}, OK: true, Book: true}
+	}
+	var spent time.Duration
+	if e.inEndgame(root) {
+		res, ok := e.searchEndgame(ctx, root, player, limits)
+		if ok {
+			return res
+		}
+		// 没解完：剩下的预算交给普通搜索
+		spent = res.Time
+		if budget := limits.Budget(); budget > 0 {
+			left := budget - spent
+			if left < time.Millisecond {
+				left = time.Millisecond
+			}
+			limits = SearchLimits{MaxDepth: limits.MaxDepth, MoveTime: left}
+		}
+	}
+
+	var (
+		res  SearchResult
+		prev map[Move]int // 上一层各根走法的分数
+	)
+	elapsed := e.deepen(ctx, root, limits, func(depth, depth2 int) bool {
+		rr := e.searchDepth(root, player, depth2, prev)
+		if e.stop.Load() || !rr.ok {
+			return false // 被打断（结果作废）或无合法走法
+		}
+		pv := e.extendPV(root, player, rr.pv, depth2)
+		e.seedPV(root, player, pv)
+		prev = rr.scores
+		res = SearchResult{Move: rr.move, Score: rr.score, Depth: depth, PV: pv, OK: true}
+		return true
+	})
+	res.Nodes = e.nodes.Load()
+	res.Time = spent + elapsed
+	return res
+}
+
+// searchEndgame 用至多一半预算（不限时则 endgameTimeCap）精确求解；
+// ok=false 表示没解完，res.Time 为已花掉的时间
+func (e *Engine) searchEndgame(ctx context.Context, root *Board, player CellState, limits SearchLimits) (res SearchResult, ok bool) {
+	limit := endgameTimeCap
+	if budget := limits.Budget(); budget > 0 {
+		limit = budget / 2
+	}
+	ctx, cancel := context.WithTimeout(ctx, limit)
+	defer cancel()
+	er := e.SolveEndgame(ctx, root, player)
+	if !er.Solved {
+		return SearchResult{Time: er.Time}, false
+	}
+	return SearchResult{
+		Move:   er.Move,
+		Score:  er.Margin,
+		Depth:  len(er.PV),
+		Nodes:  er.Nodes,
+		PV:     er.PV,
+		Time:   er.Time,
+		OK:     er.OK,
+		Solved: true,
+	}, true
+}
+
+// deepen 是迭代加深的公共驱动：按 limits 计时，逐层调用 iter(名义深度, 残局加深后的深度)。
+// iter 返回 false 即停止；第 1 层完成前不响应取消。返回总用时。
+func (e *Engine) deepen(ctx context.Context, root *Board, limits SearchLimits, iter func(depth, depth2 int) bool) time.Duration {
+	start := time.Now()
+	maxDepth := limits.MaxDepth
+	if maxDepth <= 0 {
+		maxDepth = 1
+	}
+	budget := limits.Budget()
+	if budget > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, budget)
+		defer cancel()
+	}
+
+	e.stop.Store(false)
+	defer e.stop.Store(false)
+	e.nodes.Store(0)
+	e.tt.newSearch()
+
+	for depth := 1; depth <= maxDepth; depth++ {
+		if !iter(depth, chooseEndgameDepth(root, depth)) {
+			break
+		}
+		if depth == 1 {
+			// 第 1 层完成后才挂上取消钩子
+			defer context.AfterFunc(ctx, func() { e.stop.Store(true) })()
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		// 下一层通常比这一层贵好几倍：已用掉一半预算就不再开新层
+		if budget > 0 && time.Since(start) > budget/2 {
+			break
+		}
+	}
+	return time.Since(start)
+}
+
+// seedPV 把主变例沿途每个局面的走法写进置换表，下一层搜索先试这些着。
+// 节点键与 negamax 一致，见 ttKey。
+func (e *Engine) seedPV(root *Board, player CellState, pv []Move) {
+	b := cloneBoard(root)
+	side := player
+	for _, mv := range pv {
+		key, sym := e.ttKey(b, side)
+		e.tt.storeMove(key, sym.ApplyMove(mv))
+		mMakeMoveWithUndo(b, mv, side)
+		side = Opponent(side)
+	}
+}
+
+// extendPV 置换表截断会让 α-β 收集到的主变例变短，这里顺着表里的最佳着补到 maxLen。
+func (e *Engine) extendPV(root *Board, player CellState, pv []Move, maxLen int) []Move {
+	b := cloneBoard(root)
+	side := player
+	for _, mv := range pv {
+		mMakeMoveWithUndo(b, mv, side)
+		side = Opponent(side)
+	}
+	for len(pv) < maxLen {
+		key, sym := e.ttKey(b, side)
+		mv, ok := e.tt.probeMove(key)
+		mv = sym.Inverse().ApplyMove(mv)
+		if !ok || !isLegal(b, mv, side) {
+			break
+		}
+		pv = append(pv, mv)
+		mMakeMoveWithUndo(b, mv, side)
+		side = Opponent(side)
+	}
+	return pv
+}
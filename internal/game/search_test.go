@@ -0,0 +1,95 @@
+package game
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestSearchLimitsBudget(t *testing.T) {
+	cases := []struct {
+		l    SearchLimits
+		want time.Duration
+	}{
+		{SearchLimits{}, 0},
+		{SearchLimits{MoveTime: time.Second, Remaining: time.Minute}, time.Second},
+		{SearchLimits{Remaining: 30 * time.Second, MovesToGo: 10}, 3 * time.Second},
+		{SearchLimits{Remaining: time.Second, Increment: 4 * time.Second}, 800 * time.Millisecond},
+	}
+	for _, c := range cases {
+		if got := c.l.Budget(); got != c.want {
+			t.Errorf("%+v: Budget()=%v，期望 %v", c.l, got, c.want)
+		}
+	}
+}
+
+// TestSearchCancelledStillReturnsMove 上下文已取消时仍要给出第 1 层的着法，并及时返回
+func TestSearchCancelledStillReturnsMove(t *testing.T) {
+	e := NewEngine(EngineOptions{TTEntries: 1 << 16})
+	gs := NewGameState(4)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	start := time.Now()
+	res := e.Search(ctx, gs.Board, PlayerA, SearchLimits{MaxDepth: 8})
+	if !res.OK || res.Depth != 1 {
+		t.Fatalf("期望返回第 1 层结果，得到 %+v", res)
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Fatalf("取消后搜索耗时过长: %v", time.Since(start))
+	}
+}
+
+// TestSearchReportsPV 主变例以最佳着开头、整条线合法，并带回节点数与真实分数
+func TestSearchReportsPV(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	gs := NewGameState(4)
+	for i := 0; i < 14; i++ {
+		moves := GenerateMoves(gs.Board, gs.CurrentPlayer)
+		gs.MakeMove(moves[r.Intn(len(moves))])
+	}
+
+	e := NewEngine(EngineOptions{TTEntries: 1 << 16})
+	res := e.Search(context.Background(), gs.Board, gs.CurrentPlayer, SearchLimits{MaxDepth: 3})
+	if !res.OK || res.Depth != 3 || res.Nodes == 0 {
+		t.Fatalf("结果不完整: %+v", res)
+	}
+	if len(res.PV) == 0 || res.PV[0] != res.Move {
+		t.Fatalf("PV 应以最佳着开头: move=%v pv=%v", res.Move, res.PV)
+	}
+	b := gs.Board.Clone()
+	side := gs.CurrentPlayer
+	for i, mv := range res.PV {
+		if !isLegal(b, mv, side) {
+			t.Fatalf("PV 第 %d 步 %v 不合法", i, mv)
+		}
+		mv.MakeMove(b, side)
+		side = Opponent(side)
+	}
+}
+
+// TestAnalyzeMultiPV 多 PV：条数受限、按分数降序、每条 PV 以自己的根走法开头
+func TestAnalyzeMultiPV(t *testing.T) {
+	gs := NewGameState(4)
+	e := NewEngine(EngineOptions{TTEntries: 1 << 16})
+	an := e.Analyze(context.Background(), gs.Board, PlayerA, SearchLimits{MaxDepth: 2}, AnalyzeOptions{MultiPV: 3})
+	if an.Depth != 2 || len(an.Lines) != 3 {
+		t.Fatalf("期望深度 2、3 条线，得到深度 %d、%d 条", an.Depth, len(an.Lines))
+	}
+	for i, l := range an.Lines {
+		if len(l.PV) == 0 || l.PV[0] != l.Move {
+			t.Fatalf("第 %d 条 PV 不以根走法开头: %+v", i, l)
+		}
+		if i > 0 && l.Score > an.Lines[i-1].Score {
+			t.Fatalf("线未按分数降序: %d > %d", l.Score, an.Lines[i-1].Score)
+		}
+	}
+
+	// 只分析指定走法
+	only := GenerateMoves(gs.Board, PlayerA)[:2]
+	an = e.Analyze(context.Background(), gs.Board, PlayerA, SearchLimits{MaxDepth: 1}, AnalyzeOptions{Moves: only})
+	if len(an.Lines) != 2 {
+		t.Fatalf("指定 2 个走法，得到 %d 条线", len(an.Lines))
+	}
+}
@@ -51,15 +51,12 @@ func NewGameState(radius int) *GameState {
 		_ = b.Set(c, Blocked)
 	}
 
-	// 构造 GameState
+	// 构造 GameState（行棋方不进棋盘哈希，由搜索自行异或）
 	gs := &GameState{
 		Board:         b,
 		CurrentPlayer: PlayerA,
 	}
 
-	// 把“行棋方随机键” XOR 进棋盘哈希
-	b.hash ^= zobristSide[sideIdx(gs.CurrentPlayer)]
-
 	gs.updateScores() // 计算初始分数
 	return gs
 }
@@ -112,17 +109,8 @@ func NewGameState(radius int) *GameState {
 
 // updateScores 重新统计棋子数量，更新 ScoreA 和 ScoreB
 func (gs *GameState) updateScores() {
-	a, b := 0, 0
-	for _, coord := range gs.Board.AllCoords() {
-		switch gs.Board.Get(coord) {
-		case PlayerA:
-			a++
-		case PlayerB:
-			b++
-		}
-	}
-	gs.ScoreA = a
-	gs.ScoreB = b
+	gs.ScoreA = gs.Board.CountPieces(PlayerA)
+	gs.ScoreB = gs.Board.CountPieces(PlayerB)
 }
 
 // MakeMove 尝试执行一次玩家移动，并自动处理翻转、分数更新、切换回合和结束判定
@@ -136,12 +124,7 @@ func (gs *GameState) MakeMove(m Move) ([]HexCoord, undoInfo, error) {
 
 	// 2) 更新子数 & 统计空格
 	gs.updateScores()
-	emptyCnt := 0
-	for _, c := range gs.Board.AllCoords() {
-		if gs.Board.Get(c) == Empty {
-			emptyCnt++
-		}
-	}
+	emptyCnt := gs.Board.CountPieces(Empty)
 
 	// 3) 计算“下一执子方”并检查他／她有没有合法走法
 	next := Opponent(gs.CurrentPlayer)
@@ -196,12 +179,7 @@ func (gs *GameState) MakeMove(m Move) ([]HexCoord, undoInfo, error) {
 		} else if len(nextMoves) == 0 {
 			// 如果是因为下一玩家无合法走法，将所有空格分配给当前玩家
 			totalCells := len(gs.Board.AllCoords())
-			blockedCnt := 0
-			for _, c := range gs.Board.AllCoords() {
-				if gs.Board.Get(c) == Blocked {
-					blockedCnt++
-				}
-			}
+			blockedCnt := gs.Board.CountPieces(Blocked)
 			// 注意：这里假设当前走子方是 A，且是 A 在这一步之后检查到 B 无法走
 			// 所以直接把剩余空格算到 A。你如果想兼容两种走子方，都要判断一下 gs.CurrentPlayer：
 			if gs.CurrentPlayer == PlayerA {
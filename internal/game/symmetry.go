@@ -0,0 +1,155 @@
+// internal/game/symmetry.go
+package game
+
+import "math/bits"
+
+// NumSymmetries 六角棋盘的 12 个对称：6 个旋转 × 是否先镜像。
+// 编号与 train_hex_cnn.py 里 build_permutations 的 12 个变换一一对应（rot_axial / mirror_axial）。
+const NumSymmetries = 12
+
+// Symmetry 编号 s：先（s ≥ 6 时）镜像，再逆时针转 s%6 个 60°；0 为恒等
+type Symmetry uint8
+
+// Apply 变换一个坐标；以棋盘中心为原点，变换后仍在同半径棋盘内
+func (s Symmetry) Apply(c HexCoord) HexCoord {
+	q, r := c.Q, c.R
+	if s >= 6 {
+		q = -q - r // 立方坐标里交换 x、y
+	}
+	for k := 0; k < int(s%6); k++ {
+		q, r = -r, q+r // (x,y,z) → (-z,-x,-y)
+	}
+	return HexCoord{Q: q, R: r}
+}
+
+// ApplyMove 起点终点一起变换
+func (s Symmetry) ApplyMove(m Move) Move {
+	return Move{From: s.Apply(m.From), To: s.Apply(m.To)}
+}
+
+// Inverse 逆变换：旋转取反向，带镜像的都是对合
+func (s Symmetry) Inverse() Symmetry {
+	if s >= 6 {
+		return s
+	}
+	return (6 - s) % 6
+}
+
+// ApplyBoard 返回变换后的新棋盘：棋子、障碍、上一步一起搬，哈希随之重算
+func (s Symmetry) ApplyBoard(b *Board) *Board {
+	g := b.geom
+	nb := &Board{
+		radius:    b.radius,
+		geom:      g,
+		bbA:       g.permute(b.bbA, s),
+		bbB:       g.permute(b.bbB, s),
+		bbBlocked: g.permute(b.bbBlocked, s),
+		hash:      b.symHash(s),
+	}
+	if b.LastMove != (Move{}) {
+		nb.LastMove = s.ApplyMove(b.LastMove)
+	}
+	return nb
+}
+
+// initSymmetries 生成几何表里每个对称的格子置换，由 newBoardGeom 调用
+func (g *boardGeom) initSymmetries() {
+	for s := Symmetry(0); s < NumSymmetries; s++ {
+		perm := make([]int8, len(g.coords))
+		for i, c := range g.coords {
+			perm[i] = int8(g.indexOf(s.Apply(c)))
+		}
+		g.sym[s] = perm
+	}
+}
+
+// permute 按对称 s 搬动位集合里的每一格
+func (g *boardGeom) permute(m uint64, s Symmetry) uint64 {
+	perm := g.sym[s]
+	var out uint64
+	forEachBit(m, func(i int) {
+		out |= 1 << uint(perm[i])
+	})
+	return out
+}
+
+// symHash 局面经 s 变换后的 Zobrist 哈希，不实际搬棋盘
+func (b *Board) symHash(s Symmetry) uint64 {
+	perm := b.geom.sym[s]
+	var h uint64
+	for _, p := range []CellState{PlayerA, PlayerB} {
+		forEachBit(b.mask(p), func(i int) {
+			h ^= b.geom.zobristAt(int(perm[i]), p)
+		})
+	}
+	return h
+}
+
+// canonicalHash 在保持障碍格不动的对称里取哈希最小的像。
+// 障碍不进哈希，所以会挪动障碍的对称不算（标准开局的三个障碍只留下 6 个对称）。
+// ties 是取到最小值的对称集合（位掩码，至少含一个）；局面自身对称时不止一个。
+func (b *Board) canonicalHash() (h uint64, ties uint16) {
+	for s := Symmetry(0); s < NumSymmetries; s++ {
+		if s != 0 && b.geom.permute(b.bbBlocked, s) != b.bbBlocked {
+			continue
+		}
+		sh := b.hash
+		if s != 0 {
+			sh = b.symHash(s)
+		}
+		switch {
+		case ties == 0 || sh < h:
+			h, ties = sh, 1<<s
+		case sh == h:
+			ties |= 1 << s
+		}
+	}
+	return h, ties
+}
+
+// CanonicalHash 规范哈希及把本局面变到规范朝向的对称：
+// 互为对称（且障碍格位置相同）的局面得到同一个哈希，
+// s.ApplyMove 把本局面的走法变到规范朝向，s.Inverse() 变回来。
+func (b *Board) CanonicalHash() (uint64, Symmetry) {
+	h, ties := b.canonicalHash()
+	return h, firstSym(ties)
+}
+
+// canonicalKey 规范哈希 ^ 行棋方，ties 见 canonicalHash
+func canonicalKey(b *Board, side CellState) (key uint64, ties uint16) {
+	h, ties := b.canonicalHash()
+	return h ^ zobristSide[sideIdx(side)], ties
+}
+
+// CanonicalKey 按规范局面 + 行棋方去重用的键（开局库、数据集去重）
+func CanonicalKey(b *Board, side CellState) uint64 {
+	key, _ := canonicalKey(b, side)
+	return key
+}
+
+// defaultSymmetryEmpties 开局阶段才按规范局面取置换表键：每个节点多算几次对称哈希，
+// 子少时很便宜，而且只有开局才常见不同朝向的同一局面（标准开局 52 空）
+const defaultSymmetryEmpties = 40
+
+func (e *Engine) symmetryEmpties() int {
+	if e.opts.SymmetryEmpties == 0 {
+		return defaultSymmetryEmpties
+	}
+	return e.opts.SymmetryEmpties
+}
+
+// ttKey 置换表节点键：平时是棋盘哈希 ^ 行棋方；空格够多时改用规范局面的键。
+// 表里的最佳着一律存规范朝向，sym 把本局面的走法变过去，sym.Inverse() 变回来。
+// 用不用规范键只看空格数，同一局面每次取到的键都一样。
+func (e *Engine) ttKey(b *Board, side CellState) (key uint64, sym Symmetry) {
+	if n := e.symmetryEmpties(); n > 0 && b.CountPieces(Empty) >= n {
+		key, ties := canonicalKey(b, side)
+		return key, firstSym(ties)
+	}
+	return b.hash ^ zobristSide[sideIdx(side)], 0
+}
+
+// firstSym 取 ties 里编号最小的对称
+func firstSym(ties uint16) Symmetry {
+	return Symmetry(bits.TrailingZeros16(ties))
+}
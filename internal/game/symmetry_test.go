@@ -0,0 +1,106 @@
+package game
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestSymmetryCoords(t *testing.T) {
+	c := HexCoord{Q: 3, R: -1} // 不在任何对称轴上，12 个像两两不同
+	seen := map[HexCoord]Symmetry{}
+	for s := Symmetry(0); s < NumSymmetries; s++ {
+		img := s.Apply(c)
+		if prev, dup := seen[img]; dup {
+			t.Fatalf("对称 %d 与 %d 把 %v 变到同一格 %v", s, prev, c, img)
+		}
+		seen[img] = s
+		for _, a := range AllCoords(4) {
+			if !NewBoard(4).InBounds(s.Apply(a)) {
+				t.Fatalf("对称 %d 把 %v 变出了棋盘", s, a)
+			}
+			if s.Inverse().Apply(s.Apply(a)) != a {
+				t.Fatalf("对称 %d 的逆变换不对: %v", s, a)
+			}
+			for _, d := range cloneDirs {
+				o := HexCoord{a.Q + d.Q, a.R + d.R}
+				if HexDist(s.Apply(a), s.Apply(o)) != 1 {
+					t.Fatalf("对称 %d 没有保持相邻: %v %v", s, a, o)
+				}
+			}
+		}
+	}
+}
+
+// TestSymmetryBoard 变换后的局面哈希、走法数、静态评估都与原局面一致
+func TestSymmetryBoard(t *testing.T) {
+	r := rand.New(rand.NewSource(9))
+	gs := NewGameState(4)
+	for i := 0; i < 10; i++ {
+		mvs := GenerateMoves(gs.Board, gs.CurrentPlayer)
+		gs.MakeMove(mvs[r.Intn(len(mvs))])
+	}
+	b, side := gs.Board, gs.CurrentPlayer
+	h, sym := b.CanonicalHash()
+	for s := Symmetry(0); s < NumSymmetries; s++ {
+		tb := s.ApplyBoard(b)
+		if err := tb.VerifyHash(); err != nil {
+			t.Fatalf("对称 %d: %v", s, err)
+		}
+		if got, want := len(GenerateMoves(tb, side)), len(GenerateMoves(b, side)); got != want {
+			t.Fatalf("对称 %d: %d 个走法，原局面 %d 个", s, got, want)
+		}
+		for _, mv := range GenerateMoves(b, side) {
+			if !isLegal(tb, s.ApplyMove(mv), side) {
+				t.Fatalf("对称 %d: %v 变换后不合法", s, mv)
+			}
+		}
+		if got, want := evaluateStatic(tb, side), evaluateStatic(b, side); got != want {
+			t.Fatalf("对称 %d: 静态评估 %d，原局面 %d", s, got, want)
+		}
+		if tb.bbBlocked != b.bbBlocked {
+			continue // 障碍挪了位置，不是同一个局面
+		}
+		if th, _ := tb.CanonicalHash(); th != h {
+			t.Fatalf("对称 %d: 规范哈希 %016x，原局面 %016x", s, th, h)
+		}
+	}
+	if cb := sym.ApplyBoard(b); cb.Hash() != h {
+		t.Fatalf("变到规范朝向后哈希应等于规范哈希")
+	}
+}
+
+// TestSymmetricTTShared 搜过的局面换个朝向再搜，根节点直接命中置换表；表里的着法变换回当前朝向
+func TestSymmetricTTShared(t *testing.T) {
+	r := rand.New(rand.NewSource(4))
+	gs := NewGameState(4)
+	for i := 0; i < 6; i++ {
+		mvs := GenerateMoves(gs.Board, gs.CurrentPlayer)
+		gs.MakeMove(mvs[r.Intn(len(mvs))])
+	}
+	b, side := gs.Board, gs.CurrentPlayer
+	rot := Symmetry(2).ApplyBoard(b) // 转 120°，障碍不动
+
+	e := NewEngine(EngineOptions{TTEntries: 1 << 16})
+	first := e.DeepSearch(b, 0, side, 3)
+	before := e.nodes.Load()
+	if got := e.DeepSearch(rot, 0, side, 3); got != first {
+		t.Fatalf("转过的局面分数 %d，原局面 %d", got, first)
+	}
+	if n := e.nodes.Load() - before; n != 1 {
+		t.Fatalf("转过的局面应在根节点命中，实际搜了 %d 个节点", n)
+	}
+	key, sym := e.ttKey(rot, side)
+	mv, ok := e.tt.probeMove(key)
+	if !ok || !isLegal(rot, sym.Inverse().ApplyMove(mv), side) {
+		t.Fatalf("表里的最佳着变回当前朝向后应合法: %v", sym.Inverse().ApplyMove(mv))
+	}
+
+	// 关掉之后两个朝向各算各的
+	off := NewEngine(EngineOptions{TTEntries: 1 << 16, SymmetryEmpties: -1})
+	off.DeepSearch(b, 0, side, 3)
+	before = off.nodes.Load()
+	off.DeepSearch(rot, 0, side, 3)
+	if off.nodes.Load()-before == 1 {
+		t.Fatal("SymmetryEmpties<0 时不应共享置换表")
+	}
+}
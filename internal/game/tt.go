@@ -6,23 +6,23 @@ import (
 	"math/rand"
 	"sync"
 	"sync/atomic"
-	"time"
 )
 
 // ------------------------------------------------------------
 //  Zobrist 随机键（预生成 + 零锁查询）
 // ------------------------------------------------------------
 
-// 如果你的棋盘最大半径固定，填在这里；否则可暴露为变量或在 initZobrist 里计算。
-const maxRadius = 3 // ★根据实际棋盘大小调整
+// Zobrist 键按位棋盘支持的最大半径 maxBoardRadius 生成；
+// 更小半径棋盘的格子都是它的子集，经 boardGeom.zidx 映射后共用同一张表。
+// Empty 与 Blocked 的键恒为 0，因此整盘哈希 = 所有棋子格键的异或。
 
 var (
-	zobristCell     [][4]uint64      // 下标 → 4 个状态随机数
-	hexCoordToIndex map[HexCoord]int // 坐标 → 下标
+	zobristCell     [][4]uint64 // 最大半径下的格子编号 → 4 个状态随机数
 	onceZobristInit sync.Once
 )
 
 // side-to-move Zobrist keys: index 0 = PlayerA, index 1 = PlayerB
+// 棋盘哈希本身不含行棋方，由搜索在需要时异或进节点键。
 var zobristSide [2]uint64
 
 // init 在程序启动时执行一次，生成所有随机键。
@@ -30,54 +30,98 @@ func init() {
 	initZobrist()
 }
 
-// initZobrist 预生成 maxRadius 棋盘内所有格子的 Zobrist 键。
+// zobristSeed 固定种子：哈希要跨进程稳定，开局库这类按哈希存盘的文件才能在下次启动时认出局面
+const zobristSeed = 0x48584147 // "HXAG"
+
+// initZobrist 预生成最大半径棋盘内所有格子的 Zobrist 键。
 func initZobrist() {
 	onceZobristInit.Do(func() {
 		// 1) Seed the RNG for reproducible randomness
-		rand.Seed(time.Now().UnixNano())
+		rng := rand.New(rand.NewSource(zobristSeed))
 
 		// 2) Build per-cell Zobrist keys
-		coords := AllCoords(maxRadius)
-		zobristCell = make([][4]uint64, len(coords))
-		hexCoordToIndex = make(map[HexCoord]int, len(coords))
-		for i, c := range coords {
-			hexCoordToIndex[c] = i
+		n := 3*maxBoardRadius*(maxBoardRadius+1) + 1
+		zobristCell = make([][4]uint64, n)
+		for i := range zobristCell {
 			zobristCell[i] = [4]uint64{
-				rand.Uint64(), // Empty
-				0,             // Blocked (never participates)
-				rand.Uint64(), // PlayerA
-				rand.Uint64(), // PlayerB
+				0,            // Empty (never participates)
+				0,            // Blocked (never participates)
+				rng.Uint64(), // PlayerA
+				rng.Uint64(), // PlayerB
 			}
 		}
 
 		// 3) Build side-to-move Zobrist keys
-		zobristSide[0] = rand.Uint64() // PlayerA to move
-		zobristSide[1] = rand.Uint64() // PlayerB to move
+		zobristSide[0] = rng.Uint64() // PlayerA to move
+		zobristSide[1] = rng.Uint64() // PlayerB to move
 	})
 }
 
-// zobristKey 直接数组查表，0 锁、0 原子操作。
+// zobristAt 按棋盘几何内的格子编号查键，0 锁、0 原子操作。
+func (g *boardGeom) zobristAt(i int, s CellState) uint64 {
+	return zobristCell[g.zidx[i]][s]
+}
+
+// zobristKey 按坐标查键；坐标不在最大半径棋盘内直接 panic，避免静默撞键。
 func zobristKey(c HexCoord, s CellState) uint64 {
-	return zobristCell[hexCoordToIndex[c]][s]
+	i := geomFor(maxBoardRadius).indexOf(c)
+	if i < 0 {
+		panic(fmt.Sprintf("game: zobristKey: coordinate %v outside radius %d", c, maxBoardRadius))
+	}
+	return zobristCell[i][s]
 }
 
 // hashBoard 计算整盘哈希（全盘 XOR）。
 func hashBoard(b *Board) uint64 {
 	var h uint64
-	for coord, state := range b.cells {
-		if state != Empty {
-			h ^= zobristKey(coord, state)
-		}
+	for _, s := range []CellState{PlayerA, PlayerB} {
+		forEachBit(b.mask(s), func(i int) {
+			h ^= b.geom.zobristAt(i, s)
+		})
 	}
 	return h
 }
 
+// hashCheckEnabled 打开后，每次 MakeMove/UnmakeMove 结束都全盘重算哈希做校验。
+// 只给测试/调试用，开销不小。
+var hashCheckEnabled atomic.Bool
+
+// EnableHashCheck 打开/关闭增量哈希校验；校验失败会 panic 并带上出错位置。
+func EnableHashCheck(on bool) {
+	hashCheckEnabled.Store(on)
+}
+
+// VerifyHash 重新计算整盘哈希，并与增量维护的 Hash() 比较。
+func (b *Board) VerifyHash() error {
+	if want := hashBoard(b); want != b.hash {
+		return fmt.Errorf("game: hash drift: incremental %016x, recomputed %016x", b.hash, want)
+	}
+	return nil
+}
+
+// checkHash 在校验开关打开时调用 VerifyHash，失败即 panic
+func (b *Board) checkHash(where string) {
+	if !hashCheckEnabled.Load() {
+		return
+	}
+	if err := b.VerifyHash(); err != nil {
+		panic(where + ": " + err.Error())
+	}
+}
+
 // ------------------------------------------------------------
 //  置换表（Transposition Table）
 // ------------------------------------------------------------
 
-const ttSize = 1 << 23 // 4 M entries ≈ 200 MB
-const ttMask = ttSize - 1
+const (
+	// defaultTTMB 引擎没指定大小时置换表占的内存
+	defaultTTMB = 128
+	// ttSlotBytes 一个槽位 16 字节（check + data），一个桶两个槽位
+	ttSlotBytes = 16
+	// ttGenBits 代数占 data 的高 6 位，回绕后只比相等与否
+	ttGenBits = 6
+	ttGenMask = 1<<ttGenBits - 1
+)
 
 type ttFlag uint8
 
@@ -88,68 +132,222 @@ const (
 )
 
 type ttEntry struct {
-	key     uint64 // 哈希
-	score   int32  // αβ 分值
-	depth   int16  // 深度
-	flag    ttFlag // 界类型
-	bestIdx uint8  // 根节点最佳着（可选）
+	key   uint64     // 哈希
+	score int32      // αβ 分值
+	depth int16      // 深度；-1 表示只记了最佳着、没有可用分值
+	flag  ttFlag     // 界类型
+	move  packedMove // 最佳着（0 = 无）
+	gen   uint8      // 写入时的搜索代数
 }
 
-var (
-	ttProbeCount uint64 // 总 probe 次数
-	ttHitCount   uint64 // 命中次数
-)
+// pack 把条目除 key 以外的部分压进 64 位：
+// 低 16 位最佳着 | 32 位分值 | 8 位深度（有符号）| 2 位界类型 | 6 位代数
+func (e ttEntry) pack() uint64 {
+	return uint64(e.move) |
+		uint64(uint32(e.score))<<16 |
+		uint64(uint8(int8(e.depth)))<<48 |
+		uint64(e.flag&3)<<56 |
+		uint64(e.gen&ttGenMask)<<58
+}
 
-var (
-	ttTable = make([]ttEntry, ttSize) // 切片比 map 更快
-	ttMu    [256]sync.Mutex           // 分片锁（若需并发写更安全，可选）
-)
+func unpackEntry(key, data uint64) ttEntry {
+	return ttEntry{
+		key:   key,
+		move:  packedMove(data),
+		score: int32(uint32(data >> 16)),
+		depth: int16(int8(uint8(data >> 48))),
+		flag:  ttFlag(data>>56) & 3,
+		gen:   uint8(data>>58) & ttGenMask,
+	}
+}
+
+// packedMove 把走法压进 16 位：高 8 位起点、低 8 位终点，
+// 均为最大半径下的格子编号 +1，与棋盘实际半径无关。
+type packedMove uint16
+
+func packMove(m Move) packedMove {
+	g := geomFor(maxBoardRadius)
+	from, to := g.indexOf(m.From), g.indexOf(m.To)
+	if from < 0 || to < 0 {
+		return 0
+	}
+	return packedMove((from+1)<<8 | (to + 1))
+}
+
+func (p packedMove) unpack() (Move, bool) {
+	if p == 0 {
+		return Move{}, false
+	}
+	g := geomFor(maxBoardRadius)
+	return Move{From: g.coords[int(p>>8)-1], To: g.coords[int(p&0xff)-1]}, true
+}
+
+// ttSlot 无锁槽位：check 存 key ^ data。两个字各自原子读写，
+// 并发写撕裂时 check ^ data 对不上 key，读方当作未命中，不必加锁。
+type ttSlot struct {
+	check atomic.Uint64
+	data  atomic.Uint64
+}
+
+// transTable 是单个引擎私有的置换表；Lazy SMP 的各线程无锁共享同一张表。
+// 每个桶两个槽位：0 号按深度优先替换（旧代数的条目随时可换），1 号总是替换，
+// 这样深的结果不会被浅的冲掉，新的浅结果也总有地方放。
+type transTable struct {
+	entries []ttSlot // 槽位，桶 i 占 entries[2i]、entries[2i+1]
+	mask    uint64   // 桶数 - 1
+	gen     atomic.Uint32
+
+	probes atomic.Uint64 // 总 probe 次数
+	hits   atomic.Uint64 // 命中次数
+}
+
+// ttSlotsForMB mb 兆字节能放下的槽位数；<=0 用 defaultTTMB
+func ttSlotsForMB(mb int) int {
+	if mb <= 0 {
+		mb = defaultTTMB
+	}
+	return mb << 20 / ttSlotBytes
+}
+
+// newTransTable 分配 n 个槽位的置换表，n 向下取整到 2 的幂（至少一个桶）
+func newTransTable(n int) *transTable {
+	t := &transTable{}
+	t.resize(n)
+	return t
+}
+
+// resize 重新分配 n 个槽位，旧内容全部丢弃
+func (t *transTable) resize(n int) {
+	if n <= 0 {
+		n = ttSlotsForMB(0)
+	}
+	buckets := 1
+	for buckets*4 <= n {
+		buckets *= 2
+	}
+	t.entries = make([]ttSlot, 2*buckets)
+	t.mask = uint64(buckets - 1)
+	t.gen.Store(0)
+	t.resetStats()
+}
 
-func lockFor(hash uint64) *sync.Mutex { return &ttMu[hash&255] }
+// clear 清空所有槽位，大小不变
+func (t *transTable) clear() {
+	for i := range t.entries {
+		t.entries[i].check.Store(0)
+		t.entries[i].data.Store(0)
+	}
+	t.gen.Store(0)
+	t.resetStats()
+}
 
-// probeTT 只做累加，不打印
-func probeTT(hash uint64, depth int) (bool, int, ttFlag) {
-	ttProbeCount++
-	e := ttTable[hash&ttMask]
-	if e.key == hash && int(e.depth) >= depth {
-		ttHitCount++
+// newSearch 新一次搜索开始：代数加一，之前各次搜索的条目在 0 号槽里不再受深度保护
+func (t *transTable) newSearch() {
+	t.gen.Add(1)
+}
+
+func (t *transTable) curGen() uint8 { return uint8(t.gen.Load()) & ttGenMask }
+
+// bucket 哈希对应桶的两个槽位
+func (t *transTable) bucket(hash uint64) []ttSlot {
+	i := (hash & t.mask) * 2
+	return t.entries[i : i+2]
+}
+
+// read 读一个槽位；从没写过或撕裂时 ok=false
+func (s *ttSlot) read() (e ttEntry, ok bool) {
+	data := s.data.Load()
+	check := s.check.Load()
+	if check == 0 && data == 0 {
+		return ttEntry{}, false
+	}
+	return unpackEntry(check^data, data), true
+}
+
+// write 写槽位：先写 data 再写 check，读方在两次写之间读到的是校验不过的条目
+func (s *ttSlot) write(e ttEntry) {
+	data := e.pack()
+	s.data.Store(data)
+	s.check.Store(e.key ^ data)
+}
+
+// load 在桶里找 hash 的条目，0 号槽优先
+func (t *transTable) load(hash uint64) (e ttEntry, slot int, ok bool) {
+	b := t.bucket(hash)
+	for i := range b {
+		if e, ok := b[i].read(); ok && e.key == hash {
+			return e, i, true
+		}
+	}
+	return ttEntry{}, -1, false
+}
+
+// probe 只做累加，不打印
+func (t *transTable) probe(hash uint64, depth int) (bool, int, ttFlag) {
+	t.probes.Add(1)
+	e, _, ok := t.load(hash)
+	if ok && int(e.depth) >= depth {
+		t.hits.Add(1)
 		return true, int(e.score), e.flag
 	}
 	return false, 0, 0
 }
 
-// storeTT - 写回置换表；以“深度更深者优先”策略覆盖。
-func storeTT(hash uint64, depth, score int, flag ttFlag) {
-	idx := hash & ttMask
-	if int(ttTable[idx].depth) <= depth {
-		ttTable[idx] = ttEntry{
-			key:   hash,
-			score: int32(score),
-			depth: int16(depth),
-			flag:  flag,
-		}
+// store 写回置换表。0 号槽：空的、同一局面、旧代数的、或不比新结果深的，直接覆盖；
+// 否则写进 1 号槽。读-比较-写不是原子的，并发时偶尔放错槽只影响效率不影响正确性。
+func (t *transTable) store(hash uint64, depth, score int, flag ttFlag) {
+	t.storeGen(hash, depth, score, flag, t.curGen())
+}
+
+// preload 同 store，但记在下一次搜索的代数上：搜索入口先 newSearch，
+// 预先灌进来的条目在那次搜索里仍算当前的，0 号槽里的深结果不会被浅结果冲掉
+func (t *transTable) preload(hash uint64, depth, score int, flag ttFlag) {
+	t.storeGen(hash, depth, score, flag, (t.curGen()+1)&ttGenMask)
+}
+
+func (t *transTable) storeGen(hash uint64, depth, score int, flag ttFlag, gen uint8) {
+	ne := ttEntry{key: hash, score: int32(score), depth: int16(depth), flag: flag, gen: gen}
+	if old, _, ok := t.load(hash); ok {
+		ne.move = old.move // 同一局面保留已知最佳着
+	}
+	b := t.bucket(hash)
+	if e0, ok := b[0].read(); !ok || e0.key == hash || e0.gen != gen || int(e0.depth) <= depth {
+		b[0].write(ne)
+		return
 	}
+	b[1].write(ne)
 }
 
-func probeBestIdx(hash uint64) (bool, uint8) {
-	e := ttTable[hash&ttMask]
-	if e.key == hash {
-		return true, e.bestIdx
+// probeMove 取出该局面记录的最佳着
+func (t *transTable) probeMove(hash uint64) (Move, bool) {
+	if e, _, ok := t.load(hash); ok {
+		return e.move.unpack()
 	}
-	return false, 0
+	return Move{}, false
 }
 
-func storeBestIdx(hash uint64, idx uint8) {
-	e := &ttTable[hash&ttMask]
-	if e.key == hash { // 仅写同槽
-		e.bestIdx = idx
+// storeMove 记录最佳着：同一局面直接改写；表里没有时在 1 号槽新建一个没有分值的条目（depth=-1），
+// 保证迭代加深的 PV 能播种进去。
+func (t *transTable) storeMove(hash uint64, m Move) {
+	b := t.bucket(hash)
+	if e, i, ok := t.load(hash); ok {
+		e.move = packMove(m)
+		b[i].write(e)
+		return
 	}
+	b[1].write(ttEntry{key: hash, depth: -1, move: packMove(m), gen: t.curGen()})
 }
 
-// 调用结束后，打印或获取命中率
-func GetTTStats() (probes, hits uint64, hitRate float64) {
-	probes = atomic.LoadUint64(&ttProbeCount)
-	hits = atomic.LoadUint64(&ttHitCount)
+// resetStats 清零命中计数
+func (t *transTable) resetStats() {
+	t.probes.Store(0)
+	t.hits.Store(0)
+}
+
+// stats 返回 probe 次数、命中次数与命中率（百分比）
+func (t *transTable) stats() (probes, hits uint64, hitRate float64) {
+	probes = t.probes.Load()
+	hits = t.hits.Load()
 	if probes == 0 {
 		hitRate = 0
 	} else {
@@ -158,6 +356,11 @@ func GetTTStats() (probes, hits uint64, hitRate float64) {
 	return
 }
 
+// 调用结束后，打印或获取命中率（默认引擎）
+func GetTTStats() (probes, hits uint64, hitRate float64) {
+	return DefaultEngine().tt.stats()
+}
+
 // 例如在搜索结束后调用：
 func PrintTTStats() {
 	probes, hits, rate := GetTTStats()
@@ -166,15 +369,15 @@ func PrintTTStats() {
 
 // RunSearch 是你最外层启动搜索的函数（改成你自己的名字）
 func RunSearch(b *Board, player CellState, depth int) int {
+	e := DefaultEngine()
 	// 重置计数
-	ttProbeCount = 0
-	ttHitCount = 0
+	e.tt.resetStats()
 
 	// 调用已有的 DeepSearch（你原来是：alphaBeta(b, b.hash, side, side,...)）
-	score := DeepSearch(b, b.hash, player, depth)
+	score := e.DeepSearch(b, b.hash, player, depth)
 
 	// 只在这里打印一次
-	probes, hits, rate := GetTTStats()
+	probes, hits, rate := e.tt.stats()
 	fmt.Printf("TT probes: %d, hits: %d, hit rate: %.2f%%\n",
 		probes, hits, rate)
 
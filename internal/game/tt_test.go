@@ -0,0 +1,253 @@
+package game
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+// TestZobristCoversRadius4 半径 4 的每个格子都要有独立的键，不能再全部落到下标 0
+func TestZobristCoversRadius4(t *testing.T) {
+	seen := make(map[uint64]HexCoord)
+	for _, c := range AllCoords(4) {
+		for _, s := range []CellState{PlayerA, PlayerB} {
+			k := zobristKey(c, s)
+			if prev, dup := seen[k]; dup {
+				t.Fatalf("%v 与 %v 的 Zobrist 键冲突", c, prev)
+			}
+			seen[k] = c
+		}
+	}
+}
+
+func TestZobristKeyRejectsUnknownCoord(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("越界坐标应当 panic")
+		}
+	}()
+	zobristKey(HexCoord{Q: maxBoardRadius + 1, R: 0}, PlayerA)
+}
+
+// TestIncrementalHashNoDrift 打开校验后走随机对局 + 搜索，任何一次 MakeMove/UnmakeMove 漂移都会 panic
+func TestIncrementalHashNoDrift(t *testing.T) {
+	EnableHashCheck(true)
+	defer EnableHashCheck(false)
+
+	r := rand.New(rand.NewSource(7))
+	gs := NewGameState(4)
+	if err := gs.Board.VerifyHash(); err != nil {
+		t.Fatalf("初始局面: %v", err)
+	}
+	for ply := 0; ply < 40 && !gs.GameOver; ply++ {
+		if ply%8 == 0 {
+			AlphaBeta(gs.Board, gs.CurrentPlayer, 2)
+		}
+		moves := GenerateMoves(gs.Board, gs.CurrentPlayer)
+		if _, _, err := gs.MakeMove(moves[r.Intn(len(moves))]); err != nil {
+			t.Fatal(err)
+		}
+		if err := gs.Board.VerifyHash(); err != nil {
+			t.Fatalf("ply %d: %v", ply, err)
+		}
+	}
+}
+
+// TestTTEntryPacking 条目打包后原样解出，撕裂的槽位（check 与 data 对不上）当作未命中
+func TestTTEntryPacking(t *testing.T) {
+	tt := newTransTable(1 << 8)
+	mv := Move{From: HexCoord{Q: -4, R: 4}, To: HexCoord{Q: -2, R: 3}}
+	for _, c := range []struct {
+		score, depth int
+		flag         ttFlag
+	}{{-searchInf + 1, 0, ttExact}, {searchInf - 1, 63, ttLower}, {-7, 5, ttUpper}} {
+		hash := rand.Uint64()
+		tt.store(hash, c.depth, c.score, c.flag)
+		tt.storeMove(hash, mv)
+		hit, score, flag := tt.probe(hash, c.depth)
+		if !hit || score != c.score || flag != c.flag {
+			t.Fatalf("存 %+v，读回 hit=%v score=%d flag=%d", c, hit, score, flag)
+		}
+		if got, ok := tt.probeMove(hash); !ok || got != mv {
+			t.Fatalf("最佳着读回 %v", got)
+		}
+
+		_, i, _ := tt.load(hash)
+		s := &tt.bucket(hash)[i]
+		s.data.Store(s.data.Load() ^ 1<<20) // 模拟另一线程只写了一半
+		if hit, _, _ := tt.probe(hash, 0); hit {
+			t.Fatal("撕裂的条目不应命中")
+		}
+	}
+}
+
+// TestTTReplacement 0 号槽保住深的结果，浅的落进 1 号槽；换一次搜索后旧代数的深结果可被替换
+func TestTTReplacement(t *testing.T) {
+	tt := newTransTable(2) // 只有一个桶
+	deep, shallow, next := uint64(1), uint64(2), uint64(3)
+	tt.store(deep, 8, 100, ttExact)
+	tt.store(shallow, 2, 5, ttExact)
+	if hit, _, _ := tt.probe(deep, 8); !hit {
+		t.Fatal("浅结果不应冲掉同代的深结果")
+	}
+	if hit, _, _ := tt.probe(shallow, 2); !hit {
+		t.Fatal("浅结果应存进总是替换的槽位")
+	}
+	tt.store(next, 1, 7, ttExact)
+	if hit, _, _ := tt.probe(shallow, 0); hit {
+		t.Fatal("1 号槽总是替换")
+	}
+
+	tt.newSearch()
+	tt.store(shallow, 1, 5, ttExact)
+	if hit, _, _ := tt.probe(deep, 0); hit {
+		t.Fatal("上一次搜索的深结果应让给新结果")
+	}
+	if _, i, ok := tt.load(shallow); !ok || i != 0 {
+		t.Fatalf("新结果应写进 0 号槽，实际在 %d", i)
+	}
+}
+
+func TestEngineClearResizeTT(t *testing.T) {
+	e := NewEngine(EngineOptions{TTMB: 1})
+	if got := e.TTSizeMB(); got != 1 {
+		t.Fatalf("置换表 %d MB，要的是 1 MB", got)
+	}
+	gs := NewGameState(4)
+	e.DeepSearch(gs.Board, 0, gs.CurrentPlayer, 2)
+	key, _ := e.ttKey(gs.Board, gs.CurrentPlayer)
+	if _, ok := e.tt.probeMove(key); !ok {
+		t.Fatal("搜过的根局面应在表里")
+	}
+	e.ClearTT()
+	if _, ok := e.tt.probeMove(key); ok {
+		t.Fatal("ClearTT 后不应再命中")
+	}
+	e.ResizeTT(3)
+	if got := e.TTSizeMB(); got != 2 {
+		t.Fatalf("3 MB 应向下取整到 2 MB，实际 %d MB", got)
+	}
+}
+
+// TestTTCacheRoundTrip 导出的缓存写盘读回后灌进新引擎，同一局面在根节点直接命中
+func TestTTCacheRoundTrip(t *testing.T) {
+	gs := NewGameState(4)
+	b, side := gs.Board, gs.CurrentPlayer
+	e := NewEngine(EngineOptions{TTEntries: 1 << 16})
+	want := e.DeepSearch(b, 0, side, 3)
+	c := e.ExportTT(TTCacheOptions{MinDepth: 2})
+	if c.Len() == 0 {
+		t.Fatal("搜过 3 层应导出深度 ≥2 的条目")
+	}
+	for d := range c.DepthHistogram() {
+		if d < 2 {
+			t.Fatalf("导出了深度 %d 的条目", d)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := c.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadTTCache(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Len() != c.Len() || got.Settings() != e.TTCacheSettings() {
+		t.Fatalf("读回 %d 条、设置 %+v，写出 %d 条、设置 %+v", got.Len(), got.Settings(), c.Len(), e.TTCacheSettings())
+	}
+	data := append([]byte(nil), buf.Bytes()...)
+	data[len(data)/2] ^= 1
+	if _, err := ReadTTCache(bytes.NewReader(data)); !errors.Is(err, ErrTTCacheChecksum) {
+		t.Fatalf("改坏一个字节应报校验和错误，得到 %v", err)
+	}
+
+	fresh := NewEngine(EngineOptions{TTEntries: 1 << 16})
+	if _, err := fresh.LoadTTCache(got); err != nil {
+		t.Fatal(err)
+	}
+	if score := fresh.DeepSearch(b, 0, side, 3); score != want || fresh.nodes.Load() != 1 {
+		t.Fatalf("灌了缓存的引擎分数 %d（应为 %d），搜了 %d 个节点", score, want, fresh.nodes.Load())
+	}
+	fresh.ClearTT()
+	fresh.nodes.Store(0)
+	fresh.DeepSearch(b, 0, side, 3)
+	if fresh.nodes.Load() != 1 {
+		t.Fatal("ClearTT 后应重新灌入缓存")
+	}
+
+	// 合并时同一局面留更深的
+	shallow := NewTTCache()
+	key, _ := e.ttKey(b, side)
+	shallow.add(ttEntry{key: key, depth: 1, score: 12345, flag: ttLower})
+	if err := shallow.Merge(got); err != nil {
+		t.Fatal(err)
+	}
+	if te := unpackEntry(key, shallow.entries[key]); te.depth != 3 || int(te.score) != want {
+		t.Fatalf("合并后根局面条目 %+v", te)
+	}
+}
+
+// TestTTCachePreloadGeneration 灌进来的条目在随后那次搜索里是当前代数，深结果不会被同桶的浅结果挤掉
+func TestTTCachePreloadGeneration(t *testing.T) {
+	c := NewTTCache()
+	deep, other := uint64(1), uint64(3) // 单桶表里落在同一个桶
+	c.add(ttEntry{key: deep, depth: 8, score: 100, flag: ttExact})
+	e := NewEngine(EngineOptions{TTEntries: 2})
+	if n, err := e.LoadTTCache(c); err != nil || n != 1 {
+		t.Fatalf("LoadTTCache: %d 条, %v", n, err)
+	}
+	e.tt.newSearch() // 搜索入口
+	if te, slot, ok := e.tt.load(deep); !ok || slot != 0 || te.gen != e.tt.curGen() {
+		t.Fatalf("缓存条目应在 0 号槽、记为本次搜索，得到 %+v 槽 %d（当前代数 %d）", te, slot, e.tt.curGen())
+	}
+	e.tt.store(other, 2, 5, ttExact)
+	if hit, _, _ := e.tt.probe(deep, 8); !hit {
+		t.Fatal("本次搜索的浅结果不应冲掉缓存里的深结果")
+	}
+
+	e.ClearTT()
+	e.tt.newSearch()
+	if te, _, ok := e.tt.load(deep); !ok || te.gen != e.tt.curGen() {
+		t.Fatal("ClearTT 后重灌的条目也应记为下一次搜索")
+	}
+}
+
+// TestTTCacheSettingsMismatch 评估器或静态搜索设置不同的缓存，灌和合并都应被拒
+func TestTTCacheSettingsMismatch(t *testing.T) {
+	gs := NewGameState(4)
+	e := NewEngine(EngineOptions{TTEntries: 1 << 12})
+	e.DeepSearch(gs.Board, 0, gs.CurrentPlayer, 2)
+	c := e.ExportTT(TTCacheOptions{})
+
+	var buf bytes.Buffer
+	if err := c.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadTTCache(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, opts := range []EngineOptions{
+		{Evaluator: &LinearEvaluator{}},
+		{QuiesceFlips: -1},
+		{QuiesceDelta: 7},
+		{SymmetryEmpties: -1},
+	} {
+		opts.TTEntries = 1 << 12
+		o := NewEngine(opts)
+		if n, err := o.LoadTTCache(got); !errors.Is(err, ErrTTCacheSettings) || n != 0 {
+			t.Fatalf("%+v: 应拒绝设置不符的缓存，得到 %d 条, %v", opts, n, err)
+		}
+		if err := o.ExportTT(TTCacheOptions{}).Merge(got); !errors.Is(err, ErrTTCacheSettings) {
+			t.Fatalf("%+v: 合并设置不符的缓存应报错，得到 %v", opts, err)
+		}
+	}
+	// 显式给出默认值与零值等价
+	same := NewEngine(EngineOptions{TTEntries: 1 << 12, QuiesceFlips: defaultQuiesceFlips, SymmetryEmpties: defaultSymmetryEmpties})
+	if _, err := same.LoadTTCache(got); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,315 @@
+// internal/game/ttcache.go
+package game
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"sort"
+)
+
+// 分析缓存：从置换表里挑出来的条目，存盘后下次启动再灌回去，
+// 反复分析同一批开局/测试局面时不必从头搜。
+//
+// 键与引擎置换表的键相同（Board.Hash() ^ 行棋方；开局是规范局面的键，见 ttKey），
+// 值按 ttEntry.pack 的布局存，代数位清零。分值取决于评估器、对称取键和静态搜索的设置，
+// 缓存里记着这些设置（TTCacheSettings），只灌进设置相同的引擎；
+// 残局求解表（egtt）的分值含义不同，不进缓存。
+
+// TTCache 一份分析缓存；同一个键只留最深的一条
+type TTCache struct {
+	settings TTCacheSettings
+	entries  map[uint64]uint64 // 键 → 打包的条目
+}
+
+// TTCacheSettings 建缓存的引擎里影响分值和键的设置。数都是生效值：
+// 0 已换成默认值，关掉的记 -1（QuiesceDelta 不剪时记 0）
+type TTCacheSettings struct {
+	Evaluator       string // Evaluator.Name()；直接给 Eval 函数的引擎记 "func"
+	SymmetryEmpties int
+	QuiesceFlips    int
+	QuiesceDelta    int
+}
+
+// ErrTTCacheSettings 缓存的设置与引擎（或另一份缓存）不同
+var ErrTTCacheSettings = errors.New("ttcache: 缓存的评估器/搜索设置不符")
+
+// TTCacheOptions 从置换表导出哪些条目
+type TTCacheOptions struct {
+	MinDepth  int  // 深度不足它的不要；<=0 时取 1（叶子估值没有保存价值）
+	ExactOnly bool // 只要精确值，丢掉上下界
+}
+
+func NewTTCache() *TTCache {
+	return &TTCache{entries: make(map[uint64]uint64)}
+}
+
+// Len 缓存里的局面数
+func (c *TTCache) Len() int { return len(c.entries) }
+
+// Settings 建缓存时的引擎设置；NewTTCache 出来还没并进东西的缓存为零值
+func (c *TTCache) Settings() TTCacheSettings { return c.settings }
+
+// TTCacheSettings 本引擎导出的缓存会记下的设置，LoadTTCache 也按它检查
+func (e *Engine) TTCacheSettings() TTCacheSettings {
+	name := "func"
+	if e.opts.Eval == nil {
+		name = "static"
+		if e.opts.Evaluator != nil {
+			name = e.opts.Evaluator.Name()
+		}
+	}
+	off := func(v int) int { return max(v, -1) }
+	return TTCacheSettings{
+		Evaluator:       name,
+		SymmetryEmpties: off(e.symmetryEmpties()),
+		QuiesceFlips:    off(e.quiesceFlips()),
+		QuiesceDelta:    e.quiesceDelta(),
+	}
+}
+
+// checkSettings s 与 c 的设置不同时报错；零值设置不做检查
+func (c *TTCache) checkSettings(s TTCacheSettings) error {
+	if c.settings == (TTCacheSettings{}) || s == (TTCacheSettings{}) || c.settings == s {
+		return nil
+	}
+	return fmt.Errorf("%w: 缓存 %+v，要的是 %+v", ErrTTCacheSettings, c.settings, s)
+}
+
+// betterEntry a 是否比 b 更值得留：深的优先，同深度精确值优先
+func betterEntry(a, b ttEntry) bool {
+	if a.depth != b.depth {
+		return a.depth > b.depth
+	}
+	return a.flag == ttExact && b.flag != ttExact
+}
+
+// add 放进一条；已有同键条目时留更好的那条，旧条目有最佳着而新条目没有时沿用
+func (c *TTCache) add(e ttEntry) {
+	e.gen = 0
+	if old, ok := c.entries[e.key]; ok {
+		oe := unpackEntry(e.key, old)
+		if !betterEntry(e, oe) {
+			return
+		}
+		if e.move == 0 {
+			e.move = oe.move
+		}
+	}
+	c.entries[e.key] = e.pack()
+}
+
+// Merge 把 o 并进来，同一局面取更深的结果；两边设置不同时什么也不做，返回 ErrTTCacheSettings
+func (c *TTCache) Merge(o *TTCache) error {
+	if err := o.checkSettings(c.settings); err != nil {
+		return err
+	}
+	if c.settings == (TTCacheSettings{}) {
+		c.settings = o.settings
+	}
+	for k, v := range o.entries {
+		c.add(unpackEntry(k, v))
+	}
+	return nil
+}
+
+// ExportTT 把置换表里符合 opts 的条目导成缓存；不能与搜索并发
+func (e *Engine) ExportTT(opts TTCacheOptions) *TTCache {
+	minDepth := max(opts.MinDepth, 1)
+	c := NewTTCache()
+	c.settings = e.TTCacheSettings()
+	for i := range e.tt.entries {
+		te, ok := e.tt.entries[i].read()
+		if !ok || int(te.depth) < minDepth || (opts.ExactOnly && te.flag != ttExact) {
+			continue
+		}
+		c.add(te)
+	}
+	return c
+}
+
+// LoadTTCache 检查 c 的设置与本引擎相同后把它灌进置换表，返回条数；设置不同时不灌，返回 ErrTTCacheSettings。
+// 引擎记下 c，ClearTT、ResizeTT 后重灌；c 为 nil 则不再重灌。不能与搜索并发。
+func (e *Engine) LoadTTCache(c *TTCache) (int, error) {
+	if c != nil {
+		if err := c.checkSettings(e.TTCacheSettings()); err != nil {
+			return 0, err
+		}
+	}
+	e.ttCache = c
+	return e.preloadTTCache(), nil
+}
+
+// preloadTTCache 把 e.ttCache 灌进置换表，条目记在下一次搜索的代数上（见 transTable.preload），
+// 那次搜索里深的缓存条目不会被浅结果挤掉。
+// 表比缓存小时后灌的会挤掉先灌的，按深度从浅到深灌，留下的尽量是深的。
+func (e *Engine) preloadTTCache() int {
+	c := e.ttCache
+	if c == nil {
+		return 0
+	}
+	list := make([]ttEntry, 0, len(c.entries))
+	for k, v := range c.entries {
+		list = append(list, unpackEntry(k, v))
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].depth != list[j].depth {
+			return list[i].depth < list[j].depth
+		}
+		return list[i].key < list[j].key
+	})
+	for _, te := range list {
+		e.tt.preload(te.key, int(te.depth), int(te.score), te.flag)
+		if mv, ok := te.move.unpack(); ok {
+			e.tt.storeMove(te.key, mv)
+		}
+	}
+	return len(list)
+}
+
+// 文件格式 v3（小端）：
+//
+//	"HXTT" | u32 版本 | u32 条目数
+//	u16 评估器名长度 | 评估器名 | i32 SymmetryEmpties | i32 QuiesceFlips | i32 QuiesceDelta
+//	每个条目：u64 键 | u64 打包的条目（ttEntry.pack，代数位为 0）
+//	u32 CRC32(IEEE)，覆盖上面从 "HXTT" 开始的全部字节
+//
+// 和开局库一样，键依赖 Zobrist 表；打包布局一改就要升版本号。
+// v3 布局同 v2，只是叶子改成按行棋方评估（原先按根方），旧文件的分值含义不同，不再接受。
+const (
+	ttCacheMagic   = "HXTT"
+	ttCacheVersion = 3
+	// ttCacheMaxName 评估器名的长度上限，读到更长的当文件损坏
+	ttCacheMaxName = 4096
+)
+
+var ErrTTCacheChecksum = errors.New("ttcache: 校验和不符，文件损坏或被截断")
+
+// LoadTTCacheFile 读缓存文件
+func LoadTTCacheFile(path string) (*TTCache, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	c, err := ReadTTCache(bufio.NewReader(f))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return c, nil
+}
+
+// ReadTTCache 读 v3 格式并校验 CRC；多余的尾部字节也算错
+func ReadTTCache(r io.Reader) (*TTCache, error) {
+	crc := crc32.NewIEEE()
+	tr := io.TeeReader(r, crc)
+
+	var hdr struct {
+		Magic   [4]byte
+		Version uint32
+		Count   uint32
+	}
+	if err := binary.Read(tr, binary.LittleEndian, &hdr); err != nil {
+		return nil, fmt.Errorf("ttcache: 读文件头: %w", err)
+	}
+	if string(hdr.Magic[:]) != ttCacheMagic {
+		return nil, errors.New("ttcache: 不是 HXTT 文件")
+	}
+	if hdr.Version != ttCacheVersion {
+		return nil, fmt.Errorf("ttcache: 不支持的版本 %d（需要 %d）", hdr.Version, ttCacheVersion)
+	}
+
+	c := NewTTCache()
+	var nameLen uint16
+	if err := binary.Read(tr, binary.LittleEndian, &nameLen); err != nil {
+		return nil, fmt.Errorf("ttcache: 读设置: %w", err)
+	}
+	if nameLen > ttCacheMaxName {
+		return nil, fmt.Errorf("ttcache: 评估器名长 %d，文件损坏", nameLen)
+	}
+	name := make([]byte, nameLen)
+	var nums [3]int32
+	if _, err := io.ReadFull(tr, name); err != nil {
+		return nil, fmt.Errorf("ttcache: 读设置: %w", err)
+	}
+	if err := binary.Read(tr, binary.LittleEndian, &nums); err != nil {
+		return nil, fmt.Errorf("ttcache: 读设置: %w", err)
+	}
+	c.settings = TTCacheSettings{
+		Evaluator:       string(name),
+		SymmetryEmpties: int(nums[0]),
+		QuiesceFlips:    int(nums[1]),
+		QuiesceDelta:    int(nums[2]),
+	}
+	for i := uint32(0); i < hdr.Count; i++ {
+		var rec struct{ Key, Data uint64 }
+		if err := binary.Read(tr, binary.LittleEndian, &rec); err != nil {
+			return nil, fmt.Errorf("ttcache: 读第 %d 个条目: %w", i, err)
+		}
+		c.entries[rec.Key] = rec.Data
+	}
+
+	sum := crc.Sum32()
+	var want uint32
+	if err := binary.Read(r, binary.LittleEndian, &want); err != nil {
+		return nil, fmt.Errorf("ttcache: 读校验和: %w", err)
+	}
+	if want != sum {
+		return nil, ErrTTCacheChecksum
+	}
+	if k, _ := io.Copy(io.Discard, r); k != 0 {
+		return nil, fmt.Errorf("ttcache: 文件末尾多出 %d 字节", k)
+	}
+	return c, nil
+}
+
+// Write 按 v3 格式写出（条目按键排序），ReadTTCache 能原样读回
+func (c *TTCache) Write(w io.Writer) error {
+	keys := make([]uint64, 0, len(c.entries))
+	for k := range c.entries {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	var buf bytes.Buffer
+	buf.WriteString(ttCacheMagic)
+	_ = binary.Write(&buf, binary.LittleEndian, []uint32{ttCacheVersion, uint32(len(keys))})
+	name := c.settings.Evaluator
+	if len(name) > ttCacheMaxName {
+		return fmt.Errorf("ttcache: 评估器名太长（%d 字节）", len(name))
+	}
+	_ = binary.Write(&buf, binary.LittleEndian, uint16(len(name)))
+	buf.WriteString(name)
+	_ = binary.Write(&buf, binary.LittleEndian, []int32{
+		int32(c.settings.SymmetryEmpties), int32(c.settings.QuiesceFlips), int32(c.settings.QuiesceDelta),
+	})
+	for _, k := range keys {
+		_ = binary.Write(&buf, binary.LittleEndian, []uint64{k, c.entries[k]})
+	}
+	_ = binary.Write(&buf, binary.LittleEndian, crc32.ChecksumIEEE(buf.Bytes()))
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// Save 写到文件
+func (c *TTCache) Save(path string) error {
+	var buf bytes.Buffer
+	if err := c.Write(&buf); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+// DepthHistogram 按深度统计条目数，给命令行工具打印概况
+func (c *TTCache) DepthHistogram() map[int]int {
+	h := make(map[int]int)
+	for k, v := range c.entries {
+		h[int(unpackEntry(k, v).depth)]++
+	}
+	return h
+}
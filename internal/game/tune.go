@@ -0,0 +1,101 @@
+// internal/game/tune.go
+package game
+
+import "math"
+
+// Texel 调参：用 sigmoid(K·分数) 预测对局结果，调 EvalWeights 让均方误差最小。
+// 分数对权重是线性的（见 EvalWeights.Score），所以局面只需抽一次特征，之后每轮只是加权求和。
+
+// TuneSample 一个调参局面：特征那一方视角的特征与对局结果（胜 1、平 0.5、负 0）
+type TuneSample struct {
+	F      EvalFeatures
+	Result float64
+}
+
+// TexelLoss 平均 (结果 - sigmoid(K·分数))²
+func TexelLoss(samples []TuneSample, w EvalWeights, k float64) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	var sum float64
+	for i := range samples {
+		p := 1 / (1 + math.Exp(-k*float64(w.Score(samples[i].F))))
+		d := samples[i].Result - p
+		sum += d * d
+	}
+	return sum / float64(len(samples))
+}
+
+// FitTexelK 固定权重，在 [1e-4, 1] 上按对数刻度黄金分割找使误差最小的 K
+func FitTexelK(samples []TuneSample, w EvalWeights) float64 {
+	loss := func(lk float64) float64 { return TexelLoss(samples, w, math.Exp(lk)) }
+	lo, hi := math.Log(1e-4), 0.0
+	const phi = 0.6180339887498949
+	a, b := hi-phi*(hi-lo), lo+phi*(hi-lo)
+	fa, fb := loss(a), loss(b)
+	for hi-lo > 1e-3 {
+		if fa < fb {
+			hi, b, fb = b, a, fa
+			a = hi - phi*(hi-lo)
+			fa = loss(a)
+		} else {
+			lo, a, fa = a, b, fb
+			b = lo + phi*(hi-lo)
+			fb = loss(b)
+		}
+	}
+	return math.Exp((lo + hi) / 2)
+}
+
+// TuneOptions TuneEvalWeights 的参数
+type TuneOptions struct {
+	MaxRounds int // 最多几轮（每轮把每个权重各试一遍）；<=0 为 100
+	// Progress 每轮结束调用一次，可为 nil
+	Progress func(round int, loss float64, w EvalWeights)
+}
+
+// TuneEvalWeights 从 w 出发做局部搜索：每个权重试 ±步长，变好就留下；
+// 一轮下来没有改进就把步长减半，整数权重步长到 1、BlockFill 步长到 0.0125 仍无改进时停止。
+// 返回调好的权重和它的误差。
+func TuneEvalWeights(samples []TuneSample, w EvalWeights, k float64, opts TuneOptions) (EvalWeights, float64) {
+	if opts.MaxRounds <= 0 {
+		opts.MaxRounds = 100
+	}
+	best := TexelLoss(samples, w, k)
+	step, fillStep := 8, 0.1
+	for round := 1; round <= opts.MaxRounds; round++ {
+		improved := false
+		try := func(cand EvalWeights) {
+			if l := TexelLoss(samples, cand, k); l < best {
+				w, best, improved = cand, l, true
+			}
+		}
+		for i := range tunedInts(&w) {
+			for _, d := range []int{step, -step} {
+				cand := w
+				*tunedInts(&cand)[i] += d
+				try(cand)
+			}
+		}
+		for _, d := range []float64{fillStep, -fillStep} {
+			cand := w
+			cand.BlockFill = math.Min(math.Max(cand.BlockFill+d, 0), 1)
+			try(cand)
+		}
+		if opts.Progress != nil {
+			opts.Progress(round, best, w)
+		}
+		if !improved {
+			if step == 1 && fillStep < 0.02 {
+				break
+			}
+			step, fillStep = max(step/2, 1), fillStep/2
+		}
+	}
+	return w, best
+}
+
+// tunedInts 参与调参的整数权重
+func tunedInts(w *EvalWeights) []*int {
+	return []*int{&w.Piece, &w.Edge, &w.BlockDiff, &w.CloneInf, &w.JumpInf, &w.WeakJump}
+}
@@ -0,0 +1,40 @@
+package game
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// TestTuneEvalWeights 用已知权重生成“结果”（sigmoid 概率），从默认权重出发调参应明显降低误差并靠近真值
+func TestTuneEvalWeights(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	truth := EvalWeights{Piece: 10, Edge: 1, BlockDiff: 0, BlockFill: 0.4, CloneInf: 6, JumpInf: 1, WeakJump: 20}
+	const k = 0.02
+	samples := make([]TuneSample, 2000)
+	for i := range samples {
+		f := EvalFeatures{
+			PieceDiff: r.Intn(21) - 10,
+			Edge:      r.Intn(10),
+			BlockDiff: r.Intn(5) - 2,
+			FillRatio: r.Float64(),
+			WeakJump:  r.Intn(3) - 1,
+			CloneInf:  r.Intn(9) - 4,
+			JumpInf:   r.Intn(9) - 4,
+		}
+		samples[i] = TuneSample{F: f, Result: 1 / (1 + math.Exp(-k*float64(truth.Score(f))))}
+	}
+
+	if got := FitTexelK(samples, truth); math.Abs(got-k)/k > 0.05 {
+		t.Fatalf("拟合的 K=%v，真值 %v", got, k)
+	}
+	start := DefaultEvalWeights()
+	before := TexelLoss(samples, start, k)
+	tuned, after := TuneEvalWeights(samples, start, k, TuneOptions{})
+	if after > before/10 {
+		t.Fatalf("误差只从 %v 降到 %v", before, after)
+	}
+	if tuned.Piece != truth.Piece || tuned.CloneInf != truth.CloneInf {
+		t.Fatalf("调出 %+v，真值 %+v", tuned, truth)
+	}
+}
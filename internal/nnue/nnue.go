@@ -2,67 +2,234 @@
 package nnue
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
 	"math"
+	"math/rand"
 	"os"
 )
 
+// 网络结构固定为 in → L1(ReLU) → L2(ReLU) → 1(tanh)
+const (
+	L1 = 512
+	L2 = 64
+
+	// OutputScale Eval/Forward 输出 = tanh(v) × OutputScale
+	OutputScale = 32000
+)
+
+// 文件格式 v1（小端）：
+//
+//	"HXNN" | u32 版本 | u32 输入维度 | u32 L1 | u32 L2
+//	l1w[L1][in] l1b[L1] l2w[L2][L1] l2b[L2] valw[L2] valb[1]（float32）
+//	u32 CRC32(IEEE)，覆盖上面从 "HXNN" 开始的全部字节
+const (
+	fileMagic   = "HXNN"
+	fileVersion = 1
+	maxInDim    = 1 << 16
+)
+
+var ErrChecksum = errors.New("nnue: 校验和不符，文件损坏或被截断")
+
 type Net struct {
+	// l1w 按特征列存 [in][L1]（文件里是 [L1][in]），累加器增减一个特征就是加减一列
 	l1w, l2w, valw []float32
-	l1b, l2b, valb []float32
+	l1b, l2b       []float32
+	valb           float32
 	inDim          int
 }
 
+// Accumulator 第一层的线性部分（未过 ReLU）：l1b + Σ 激活特征的列
+type Accumulator struct {
+	V [L1]float32
+}
+
+// InputDim 输入特征数
+func (n *Net) InputDim() int { return n.inDim }
+
 func Load(path string) (*Net, error) {
-	f, _ := os.Open(path)
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
 	defer f.Close()
-	var hdr [4]int32
-	binary.Read(f, binary.LittleEndian, &hdr)
-	in := int(hdr[0])
-	net := &Net{inDim: in}
+	net, err := Read(bufio.NewReader(f))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return net, nil
+}
+
+// Read 读 v1 格式并校验 CRC；多余的尾部字节也算错
+func Read(r io.Reader) (*Net, error) {
+	crc := crc32.NewIEEE()
+	tr := io.TeeReader(r, crc)
+
+	var hdr struct {
+		Magic   [4]byte
+		Version uint32
+		In, H1  uint32
+		H2      uint32
+	}
+	if err := binary.Read(tr, binary.LittleEndian, &hdr); err != nil {
+		return nil, fmt.Errorf("nnue: 读文件头: %w", err)
+	}
+	if string(hdr.Magic[:]) != fileMagic {
+		return nil, errors.New("nnue: 不是 HXNN 文件")
+	}
+	if hdr.Version != fileVersion {
+		return nil, fmt.Errorf("nnue: 不支持的版本 %d（需要 %d）", hdr.Version, fileVersion)
+	}
+	if hdr.H1 != L1 || hdr.H2 != L2 {
+		return nil, fmt.Errorf("nnue: 隐层 %d/%d，本程序只支持 %d/%d", hdr.H1, hdr.H2, L1, L2)
+	}
+	if hdr.In == 0 || hdr.In > maxInDim {
+		return nil, fmt.Errorf("nnue: 输入维度 %d 不合理", hdr.In)
+	}
+
+	in := int(hdr.In)
+	var rerr error
 	read := func(n int) []float32 {
 		buf := make([]float32, n)
-		binary.Read(f, binary.LittleEndian, buf)
+		if rerr == nil {
+			rerr = binary.Read(tr, binary.LittleEndian, buf)
+		}
 		return buf
 	}
-	net.l1w = read(in * 512)
-	net.l1b = read(512)
-	net.l2w = read(512 * 64)
-	net.l2b = read(64)
-	net.valw = read(64)
-	net.valb = read(1)
+	l1w := read(in * L1)
+	net := &Net{inDim: in}
+	net.l1b = read(L1)
+	net.l2w = read(L2 * L1)
+	net.l2b = read(L2)
+	net.valw = read(L2)
+	net.valb = read(1)[0]
+	if rerr != nil {
+		return nil, fmt.Errorf("nnue: 读权重: %w", rerr)
+	}
+
+	sum := crc.Sum32()
+	var want uint32
+	if err := binary.Read(r, binary.LittleEndian, &want); err != nil {
+		return nil, fmt.Errorf("nnue: 读校验和: %w", err)
+	}
+	if want != sum {
+		return nil, ErrChecksum
+	}
+	if k, _ := io.Copy(io.Discard, r); k != 0 {
+		return nil, fmt.Errorf("nnue: 文件末尾多出 %d 字节", k)
+	}
+
+	net.l1w = make([]float32, in*L1)
+	for o := 0; o < L1; o++ {
+		for i := 0; i < in; i++ {
+			net.l1w[i*L1+o] = l1w[o*in+i]
+		}
+	}
 	return net, nil
 }
 
-func (n *Net) Eval(inp []float32) float32 {
-	// FC1
-	h1 := make([]float32, 512)
-	for o := 0; o < 512; o++ {
-		s := n.l1b[o]
-		for i := 0; i < n.inDim; i++ {
-			s += inp[i] * n.l1w[o*n.inDim+i]
+// Write 按 v1 格式写出，Read 能原样读回
+func (n *Net) Write(w io.Writer) error {
+	var buf bytes.Buffer
+	buf.WriteString(fileMagic)
+	_ = binary.Write(&buf, binary.LittleEndian, []uint32{fileVersion, uint32(n.inDim), L1, L2})
+	l1w := make([]float32, n.inDim*L1)
+	for i := 0; i < n.inDim; i++ {
+		for o := 0; o < L1; o++ {
+			l1w[o*n.inDim+i] = n.l1w[i*L1+o]
+		}
+	}
+	for _, t := range [][]float32{l1w, n.l1b, n.l2w, n.l2b, n.valw, {n.valb}} {
+		_ = binary.Write(&buf, binary.LittleEndian, t)
+	}
+	_ = binary.Write(&buf, binary.LittleEndian, crc32.ChecksumIEEE(buf.Bytes()))
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// Random 随机小权重的网络，给测试和调通流程用
+func Random(inDim int, seed int64) *Net {
+	r := rand.New(rand.NewSource(seed))
+	fill := func(k int, scale float32) []float32 {
+		s := make([]float32, k)
+		for i := range s {
+			s[i] = (r.Float32()*2 - 1) * scale
 		}
-		if s < 0 {
-			s = 0
+		return s
+	}
+	return &Net{
+		inDim: inDim,
+		l1w:   fill(inDim*L1, 0.1),
+		l1b:   fill(L1, 0.05),
+		l2w:   fill(L2*L1, 0.05),
+		l2b:   fill(L2, 0.05),
+		valw:  fill(L2, 0.2),
+		valb:  r.Float32()*0.1 - 0.05,
+	}
+}
+
+// Refresh 按激活特征从头算累加器
+func (n *Net) Refresh(acc *Accumulator, features []int) {
+	copy(acc.V[:], n.l1b)
+	for _, f := range features {
+		n.AddFeature(acc, f)
+	}
+}
+
+// AddFeature 特征 f 从 0 变 1
+func (n *Net) AddFeature(acc *Accumulator, f int) {
+	col := n.l1w[f*L1 : (f+1)*L1]
+	for o := range acc.V {
+		acc.V[o] += col[o]
+	}
+}
+
+// SubFeature 特征 f 从 1 变 0
+func (n *Net) SubFeature(acc *Accumulator, f int) {
+	col := n.l1w[f*L1 : (f+1)*L1]
+	for o := range acc.V {
+		acc.V[o] -= col[o]
+	}
+}
+
+// Forward 从累加器算到输出：ReLU → FC2 → ReLU → 输出
+func (n *Net) Forward(acc *Accumulator) float32 {
+	var h1 [L1]float32
+	for o, s := range acc.V {
+		if s > 0 {
+			h1[o] = s
 		}
-		h1[o] = s
 	}
-	// FC2
-	h2 := make([]float32, 64)
-	for o := 0; o < 64; o++ {
+	val := n.valb
+	for o := 0; o < L2; o++ {
 		s := n.l2b[o]
-		for i := 0; i < 512; i++ {
-			s += h1[i] * n.l2w[o*512+i]
+		row := n.l2w[o*L1 : (o+1)*L1]
+		for i, h := range h1 {
+			s += h * row[i]
 		}
-		if s < 0 {
-			s = 0
+		if s > 0 {
+			val += s * n.valw[o]
 		}
-		h2[o] = s
 	}
-	// Val
-	val := n.valb[0]
-	for i := 0; i < 64; i++ {
-		val += h2[i] * n.valw[i]
+	return float32(math.Tanh(float64(val))) * OutputScale
+}
+
+// Eval 稠密输入的完整前向（输入不必是 0/1）
+func (n *Net) Eval(inp []float32) float32 {
+	var acc Accumulator
+	copy(acc.V[:], n.l1b)
+	for i := 0; i < n.inDim; i++ {
+		if x := inp[i]; x != 0 {
+			col := n.l1w[i*L1 : (i+1)*L1]
+			for o := range acc.V {
+				acc.V[o] += x * col[o]
+			}
+		}
 	}
-	return float32(math.Tanh(float64(val))) * 32000.0
+	return n.Forward(&acc)
 }
@@ -0,0 +1,60 @@
+package nnue
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"testing"
+)
+
+// TestWriteReadRoundTrip 写出再读回，评估结果不变；改一个字节就过不了校验
+func TestWriteReadRoundTrip(t *testing.T) {
+	net := Random(20, 1)
+	var buf bytes.Buffer
+	if err := net.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Read(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	inp := make([]float32, 20)
+	for i := range inp {
+		inp[i] = float32(i % 2)
+	}
+	if a, b := net.Eval(inp), got.Eval(inp); a != b {
+		t.Fatalf("读回后评估 %v，原来 %v", b, a)
+	}
+
+	data := buf.Bytes()
+	bad := append([]byte(nil), data...)
+	bad[len(bad)/2] ^= 1
+	if _, err := Read(bytes.NewReader(bad)); !errors.Is(err, ErrChecksum) {
+		t.Fatalf("损坏的文件应报校验错误，得到 %v", err)
+	}
+	bad = append([]byte(nil), data...)
+	bad[4] = 9 // 版本号
+	if _, err := Read(bytes.NewReader(bad)); err == nil {
+		t.Fatal("错误版本应报错")
+	}
+	if _, err := Read(bytes.NewReader(data[:len(data)-10])); err == nil {
+		t.Fatal("截断的文件应报错")
+	}
+}
+
+// TestAccumulatorMatchesEval 增删特征后的累加器与稠密输入的完整前向一致
+func TestAccumulatorMatchesEval(t *testing.T) {
+	net := Random(30, 2)
+	var acc Accumulator
+	net.Refresh(&acc, []int{1, 5, 7})
+	net.AddFeature(&acc, 12)
+	net.SubFeature(&acc, 5)
+
+	inp := make([]float32, 30)
+	for _, f := range []int{1, 7, 12} {
+		inp[f] = 1
+	}
+	if a, b := net.Forward(&acc), net.Eval(inp); math.Abs(float64(a-b)) > 1e-2 {
+		t.Fatalf("增量 %v，完整前向 %v", a, b)
+	}
+}
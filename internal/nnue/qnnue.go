@@ -0,0 +1,258 @@
+// internal/nnue/qnnue.go 量化版：第一层 int16，隐层权重 int8，评估过程不分配内存
+package nnue
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"os"
+)
+
+// 定点方案（全部是 2 的幂，移位即可还原）：
+//
+//	第一层   w1q = round(w1·2^E1)，b1q = round(b1·2^E1)，int16；累加器 acc = Σw1q + b1q，同为 2^E1 倍
+//	h1       = max(acc, 0)，仍是 int16，不截断（E1 选得保证任何输入下累加器都不溢出）
+//	第二层   w2q = round(w2·2^E2) ∈ [-127,127]，int8；b2q = round(b2·2^(E1+E2))，int32
+//	         s2 = b2q + Σ h1·w2q，int32，2^(E1+E2) 倍；h2 = max(s2, 0)
+//	输出层   vq = round(valw·2^E3) ∈ [-127,127]，int8；vbq = round(valb·2^(E1+E2+E3))，int64
+//	         out = vbq + Σ h2·vq，2^(E1+E2+E3) 倍，还原后同 Net 一样过 tanh × OutputScale
+//
+// 文件格式 v1（小端）：
+//
+//	"HXNQ" | u32 版本 | u32 输入维度 | u32 L1 | u32 L2 | u32 E1 | u32 E2 | u32 E3
+//	l1w int16[in][L1]（按特征列） | l1b int16[L1]
+//	l2w int8[L1][L2]（按输入行，与 HXNN 的 [L2][L1] 相反） | l2b int32[L2] | valw int8[L2] | valb int64
+//	u32 CRC32(IEEE)，覆盖上面从 "HXNQ" 开始的全部字节
+const (
+	qFileMagic   = "HXNQ"
+	qFileVersion = 1
+)
+
+type QNet struct {
+	inDim      int
+	e1, e2, e3 uint32
+	l1w        []int16 // [in][L1]
+	l1b        [L1]int16
+	l2w        [L1][L2]int8 // 按输入行存，前向时只扫非零的 h1
+	l2b        [L2]int32
+	valw       [L2]int8
+	valb       int64
+}
+
+// QAccumulator 量化第一层的累加器，2^E1 倍
+type QAccumulator struct {
+	V [L1]int16
+}
+
+// InputDim 输入特征数
+func (q *QNet) InputDim() int { return q.inDim }
+
+// Quantize 把浮点网络量化。E1 按“所有特征同时激活”的最坏情况选，累加器不会溢出；
+// E2、E3 让隐层权重恰好用满 int8。
+func Quantize(n *Net) *QNet {
+	q := &QNet{inDim: n.inDim}
+
+	// 第一层：每个输出的 |b| + Σ|w| 是累加器的上界，再给舍入误差留 in/2
+	var bound float64
+	for o := 0; o < L1; o++ {
+		s := math.Abs(float64(n.l1b[o]))
+		for i := 0; i < n.inDim; i++ {
+			s += math.Abs(float64(n.l1w[i*L1+o]))
+		}
+		bound = math.Max(bound, s)
+	}
+	q.e1 = fitExp(float64(max(math.MaxInt16-n.inDim/2-1, 1)), bound, 14)
+	s1 := math.Ldexp(1, int(q.e1))
+	q.l1w = make([]int16, len(n.l1w))
+	for i, w := range n.l1w {
+		q.l1w[i] = int16(math.Round(float64(w) * s1))
+	}
+	for o, b := range n.l1b {
+		q.l1b[o] = int16(math.Round(float64(b) * s1))
+	}
+
+	// 第二层
+	q.e2 = fitExp(127, maxAbs(n.l2w), 14)
+	// b2 放大到 2^(E1+E2) 后也别太大，留出 Σh1·w2 的空间
+	for q.e2 > 0 && maxAbs(n.l2b)*math.Ldexp(1, int(q.e1+q.e2)) > 1<<24 {
+		q.e2--
+	}
+	s2 := math.Ldexp(1, int(q.e2))
+	for o := 0; o < L2; o++ {
+		for i := 0; i < L1; i++ {
+			q.l2w[i][o] = int8(math.Round(float64(n.l2w[o*L1+i]) * s2))
+		}
+		q.l2b[o] = int32(math.Round(float64(n.l2b[o]) * math.Ldexp(1, int(q.e1+q.e2))))
+	}
+
+	// 输出层
+	q.e3 = fitExp(127, maxAbs(n.valw), 14)
+	for o, w := range n.valw {
+		q.valw[o] = int8(math.Round(float64(w) * math.Ldexp(1, int(q.e3))))
+	}
+	q.valb = int64(math.Round(float64(n.valb) * math.Ldexp(1, int(q.e1+q.e2+q.e3))))
+	return q
+}
+
+// fitExp 最大的 e ≤ limit 使 x·2^e ≤ max；x 为 0 时取 limit
+func fitExp(max, x float64, limit uint32) uint32 {
+	if x == 0 {
+		return limit
+	}
+	e := math.Floor(math.Log2(max / x))
+	if e < 0 {
+		return 0
+	}
+	return min(uint32(e), limit)
+}
+
+func maxAbs(s []float32) float64 {
+	var m float64
+	for _, v := range s {
+		m = math.Max(m, math.Abs(float64(v)))
+	}
+	return m
+}
+
+func LoadQuantized(path string) (*QNet, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	q, err := ReadQuantized(bufio.NewReader(f))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return q, nil
+}
+
+// ReadQuantized 读 HXNQ v1 并校验 CRC
+func ReadQuantized(r io.Reader) (*QNet, error) {
+	crc := crc32.NewIEEE()
+	tr := io.TeeReader(r, crc)
+
+	var hdr struct {
+		Magic      [4]byte
+		Version    uint32
+		In, H1, H2 uint32
+		E1, E2, E3 uint32
+	}
+	if err := binary.Read(tr, binary.LittleEndian, &hdr); err != nil {
+		return nil, fmt.Errorf("nnue: 读文件头: %w", err)
+	}
+	if string(hdr.Magic[:]) != qFileMagic {
+		return nil, errors.New("nnue: 不是 HXNQ 文件")
+	}
+	if hdr.Version != qFileVersion {
+		return nil, fmt.Errorf("nnue: 不支持的版本 %d（需要 %d）", hdr.Version, qFileVersion)
+	}
+	if hdr.H1 != L1 || hdr.H2 != L2 {
+		return nil, fmt.Errorf("nnue: 隐层 %d/%d，本程序只支持 %d/%d", hdr.H1, hdr.H2, L1, L2)
+	}
+	if hdr.In == 0 || hdr.In > maxInDim || hdr.E1 > 14 || hdr.E2 > 14 || hdr.E3 > 14 {
+		return nil, fmt.Errorf("nnue: 文件头不合理: in=%d E=%d/%d/%d", hdr.In, hdr.E1, hdr.E2, hdr.E3)
+	}
+
+	q := &QNet{inDim: int(hdr.In), e1: hdr.E1, e2: hdr.E2, e3: hdr.E3}
+	q.l1w = make([]int16, q.inDim*L1)
+	for _, p := range []any{q.l1w, &q.l1b, &q.l2w, &q.l2b, &q.valw, &q.valb} {
+		if err := binary.Read(tr, binary.LittleEndian, p); err != nil {
+			return nil, fmt.Errorf("nnue: 读权重: %w", err)
+		}
+	}
+
+	sum := crc.Sum32()
+	var want uint32
+	if err := binary.Read(r, binary.LittleEndian, &want); err != nil {
+		return nil, fmt.Errorf("nnue: 读校验和: %w", err)
+	}
+	if want != sum {
+		return nil, ErrChecksum
+	}
+	if k, _ := io.Copy(io.Discard, r); k != 0 {
+		return nil, fmt.Errorf("nnue: 文件末尾多出 %d 字节", k)
+	}
+	return q, nil
+}
+
+// Write 按 HXNQ v1 写出
+func (q *QNet) Write(w io.Writer) error {
+	var buf bytes.Buffer
+	buf.WriteString(qFileMagic)
+	_ = binary.Write(&buf, binary.LittleEndian, []uint32{qFileVersion, uint32(q.inDim), L1, L2, q.e1, q.e2, q.e3})
+	for _, p := range []any{q.l1w, &q.l1b, &q.l2w, &q.l2b, &q.valw, q.valb} {
+		_ = binary.Write(&buf, binary.LittleEndian, p)
+	}
+	_ = binary.Write(&buf, binary.LittleEndian, crc32.ChecksumIEEE(buf.Bytes()))
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// Refresh 按激活特征从头算累加器
+func (q *QNet) Refresh(acc *QAccumulator, features []int) {
+	acc.V = q.l1b
+	for _, f := range features {
+		q.AddFeature(acc, f)
+	}
+}
+
+// AddFeature 特征 f 从 0 变 1
+func (q *QNet) AddFeature(acc *QAccumulator, f int) {
+	col := (*[L1]int16)(q.l1w[f*L1 : (f+1)*L1])
+	for o := range acc.V {
+		acc.V[o] += col[o]
+	}
+}
+
+// SubFeature 特征 f 从 1 变 0
+func (q *QNet) SubFeature(acc *QAccumulator, f int) {
+	col := (*[L1]int16)(q.l1w[f*L1 : (f+1)*L1])
+	for o := range acc.V {
+		acc.V[o] -= col[o]
+	}
+}
+
+// Forward 整数前向，只在最后还原成浮点；不分配内存。
+// ReLU 后 h1 约一半是 0，第二层按行累加、跳过 0，比逐个输出做点积少一半乘法
+func (q *QNet) Forward(acc *QAccumulator) float32 {
+	s2 := q.l2b
+	for i, v := range acc.V {
+		if v > 0 {
+			axpyI8(&s2, int32(v), &q.l2w[i])
+		}
+	}
+	out := q.valb
+	for o, s := range s2 {
+		if s > 0 {
+			out += int64(s) * int64(q.valw[o])
+		}
+	}
+	v := math.Ldexp(float64(out), -int(q.e1+q.e2+q.e3))
+	return float32(math.Tanh(v)) * OutputScale
+}
+
+// Eval 0/1 输入的完整前向（非零即视为激活），与 Net.Eval 对照用
+func (q *QNet) Eval(inp []float32) float32 {
+	var acc QAccumulator
+	acc.V = q.l1b
+	for i := 0; i < q.inDim; i++ {
+		if inp[i] != 0 {
+			q.AddFeature(&acc, i)
+		}
+	}
+	return q.Forward(&acc)
+}
+
+// axpyI8 dst += a·w。定长数组，编译器能消掉边界检查；
+// 以后要换汇编只需替换这一个函数
+func axpyI8(dst *[L2]int32, a int32, w *[L2]int8) {
+	for o := range dst {
+		dst[o] += a * int32(w[o])
+	}
+}
@@ -0,0 +1,106 @@
+package nnue
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// randomInputs n 组 0/1 输入，大约三分之一激活，和棋盘特征的稀疏度差不多
+func randomInputs(dim, n int, seed int64) [][]float32 {
+	r := rand.New(rand.NewSource(seed))
+	out := make([][]float32, n)
+	for k := range out {
+		out[k] = make([]float32, dim)
+		for i := range out[k] {
+			if r.Intn(3) == 0 {
+				out[k][i] = 1
+			}
+		}
+	}
+	return out
+}
+
+// TestQuantizedMatchesFloat 量化前向与浮点前向的差在容差内
+func TestQuantizedMatchesFloat(t *testing.T) {
+	const tol = 0.01 * OutputScale
+	for seed := int64(1); seed <= 3; seed++ {
+		net := Random(183, seed)
+		q := Quantize(net)
+		var worst float64
+		for _, inp := range randomInputs(183, 200, seed) {
+			a, b := net.Eval(inp), q.Eval(inp)
+			worst = math.Max(worst, math.Abs(float64(a-b)))
+		}
+		t.Logf("seed %d: E=%d/%d/%d 最大误差 %.1f", seed, q.e1, q.e2, q.e3, worst)
+		if worst > tol {
+			t.Fatalf("seed %d: 量化误差 %.1f 超过 %.0f", seed, worst, tol)
+		}
+	}
+}
+
+// TestQuantizedWriteRead HXNQ 写出读回逐位一致，损坏能查出来
+func TestQuantizedWriteRead(t *testing.T) {
+	q := Quantize(Random(40, 4))
+	var buf bytes.Buffer
+	if err := q.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadQuantized(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, inp := range randomInputs(40, 20, 4) {
+		if a, b := q.Eval(inp), got.Eval(inp); a != b {
+			t.Fatalf("读回后评估 %v，原来 %v", b, a)
+		}
+	}
+
+	bad := append([]byte(nil), buf.Bytes()...)
+	bad[len(bad)/2] ^= 1
+	if _, err := ReadQuantized(bytes.NewReader(bad)); !errors.Is(err, ErrChecksum) {
+		t.Fatalf("损坏的文件应报校验错误，得到 %v", err)
+	}
+	if _, err := ReadQuantized(bytes.NewReader(buf.Bytes()[:len(buf.Bytes())-10])); err == nil {
+		t.Fatal("截断的文件应报错")
+	}
+}
+
+// TestQuantizedAccumulator 整数累加器增量更新与从头算完全相同，Forward 不分配内存
+func TestQuantizedAccumulator(t *testing.T) {
+	q := Quantize(Random(30, 5))
+	var inc, ref QAccumulator
+	q.Refresh(&inc, []int{1, 5, 7})
+	q.AddFeature(&inc, 12)
+	q.SubFeature(&inc, 5)
+	q.Refresh(&ref, []int{1, 7, 12})
+	if inc != ref {
+		t.Fatal("增量累加器与从头计算不一致")
+	}
+
+	if n := testing.AllocsPerRun(100, func() { q.Forward(&inc) }); n != 0 {
+		t.Fatalf("Forward 每次分配 %v 次", n)
+	}
+}
+
+func BenchmarkForward(b *testing.B) {
+	net := Random(183, 1)
+	var acc Accumulator
+	net.Refresh(&acc, []int{1, 5, 7, 60, 90, 150})
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		net.Forward(&acc)
+	}
+}
+
+func BenchmarkQuantizedForward(b *testing.B) {
+	q := Quantize(Random(183, 1))
+	var acc QAccumulator
+	q.Refresh(&acc, []int{1, 5, 7, 60, 90, 150})
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		q.Forward(&acc)
+	}
+}
@@ -13,6 +13,8 @@ import (
 type UIState struct {
 	From       *game.HexCoord            // 当前选中的起点（nil 表示未选中）
 	MoveScores map[game.HexCoord]float64 // 起点到各个合法终点的评估分数
+	// Explain 各终点走完后静态评估的逐项拆解，鼠标停在终点上时显示
+	Explain map[game.HexCoord]game.EvalExplanation
 }
 
 func getBoardTransform(tileImg *ebiten.Image) (scale, orgX, orgY, tileW, tileH, vs float64) {
@@ -104,29 +106,8 @@ func (gs *GameScreen) handleInput() {
 			gs.audioManager.Play("select_piece")
 
 			/* === 新增：计算 MoveScores === */
-			gs.ui.From = gs.selected
-			gs.ui.MoveScores = make(map[game.HexCoord]float64)
-
-			moves := game.GenerateMoves(gs.state.Board, gs.state.CurrentPlayer)
-			for _, mv := range moves {
-				// 只关心从选中起点出的走法
-				if mv.From != *gs.selected {
-					continue
-				}
-				// 在副本上模拟这步
-				bCopy := gs.state.Board.Clone()
-				bCopy.LastMove = mv
-
-				if _, err := mv.Apply(bCopy, gs.state.CurrentPlayer); err != nil {
-					continue
-				}
-				// 评分：深度 4 举例
-				//score := game.AlphaBeta(bCopy, gs.state.CurrentPlayer, 4)
-				//score := game.Evaluate(bCopy, gs.state.CurrentPlayer)
-				if gs.showScores {
-					score := game.Evaluate(bCopy, player)
-					gs.ui.MoveScores[mv.To] = float64(score)
-				}
+			if gs.showScores {
+				gs.refreshMoveScores()
 			}
 			/* === end 新增 === */
 
@@ -2,6 +2,8 @@
 package ui
 
 import (
+	"context"
+
 	"github.com/hajimehoshi/ebiten/v2"
 	"hexxagon_go/internal/game"
 	"math"
@@ -175,27 +177,40 @@ func (gs *GameScreen) refreshMoveScores() {
 
 	gs.ui.From = &sel
 	gs.ui.MoveScores = make(map[game.HexCoord]float64)
+	if gs.tipEngine == nil {
+		return // 没开 -tip，不打分
+	}
 
-	moves := game.GenerateMoves(gs.state.Board, player)
-	for _, mv := range moves {
-		if mv.From != sel {
-			continue
-		}
-
-		bCopy := gs.state.Board.Clone()
-
-		// 关键：告诉评估“上一手就是这步”
-		bCopy.LastMove = mv
-
-		// 真正把这步下到 bCopy 上（会翻子）
-		if _, err := mv.Apply(bCopy, player); err != nil {
-			continue
+	var fromSel []game.Move
+	for _, mv := range game.GenerateMoves(gs.state.Board, player) {
+		if mv.From == sel {
+			fromSel = append(fromSel, mv)
 		}
+	}
+	if len(fromSel) == 0 {
+		return
+	}
 
-		// 用静态评估（或深度=0 的 AlphaBetaNoTT）
-		// score := game.AlphaBetaNoTT(bCopy, player, 0)
-		score := game.Evaluate(bCopy, player)
+	// 用引擎的多 PV 分析给每个落点打分（浅层、限时，不阻塞太久）
+	an := gs.tipEngine.Analyze(context.Background(), gs.state.Board.Clone(), player,
+		game.SearchLimits{MaxDepth: tipDepth, MoveTime: tipMoveTime},
+		game.AnalyzeOptions{Moves: fromSel})
+	for _, l := range an.Lines {
+		gs.ui.MoveScores[l.Move.To] = float64(l.Score)
+	}
 
-		gs.ui.MoveScores[mv.To] = float64(score)
+	// 每个落点走完后的静态评估拆解，看分数是哪几项贡献的；
+	// 按分析引擎的权重拆，学出来的评估器没有逐项可拆，不显示
+	w, ok := gs.tipEngine.EvalWeights()
+	if !ok {
+		gs.ui.Explain = nil
+		return
+	}
+	gs.ui.Explain = make(map[game.HexCoord]game.EvalExplanation, len(fromSel))
+	b := gs.state.Board.Clone()
+	for _, mv := range fromSel {
+		_, undo := mv.MakeMove(b, player)
+		gs.ui.Explain[mv.To] = w.Explain(b, player)
+		b.UnmakeMove(undo)
 	}
 }
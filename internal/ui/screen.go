@@ -2,6 +2,7 @@
 package ui
 
 import (
+	"context"
 	"fmt"
 	"github.com/hajimehoshi/ebiten/v2/audio"
 	"github.com/hajimehoshi/ebiten/v2/text"
@@ -76,7 +77,15 @@ var soundDurations = map[string]time.Duration{
 	// 如果还有别的 key 也记得加上
 }
 
-const depth = 4 //人机思考步数
+const depth = 4                    //人机思考步数
+const aiMoveTime = 3 * time.Second // 人机每步思考时间上限
+const aiEndgameEmpties = 4         // 空格数 ≤ 它时人机改用残局精确求解
+const aiTTMB = 64                  // 人机置换表大小（MB）
+const aiPlayouts = 1600            // -ai mcts 时每步模拟次数上限（同样受 aiMoveTime 约束）
+const (
+	tipDepth    = 2                      // -tip 评分的分析深度
+	tipMoveTime = 150 * time.Millisecond // -tip 评分的分析时间上限
+)
 const (
 	// 窗口尺寸
 	WindowWidth  = 800
@@ -119,6 +128,10 @@ type GameScreen struct {
 	showScores bool
 
 	fontFace font.Face
+
+	engine    game.Searcher          // AI 方专用搜索器（α-β 或 MCTS）
+	aiResult  chan game.SearchResult // 非 nil 表示 AI 正在后台思考
+	tipEngine *game.Engine           // -tip 评分用的分析引擎
 }
 
 type ReplayStep struct {
@@ -130,8 +143,24 @@ type ReplayMatch struct {
 	Steps  []ReplayStep `json:"steps"`
 }
 
-// NewGameScreen 构造并初始化游戏界面
-func NewGameScreen(ctx *audio.Context, aiEnabled, showScores bool) (*GameScreen, error) {
+// newAI 按 -ai 参数创建人机用的搜索器：ab=α-β 引擎，mcts=蒙特卡洛树搜索；
+// ev 为 nil 时 ab 用静态评估、mcts 用网络
+func newAI(kind string, ev game.Evaluator) (game.Searcher, error) {
+	switch kind {
+	case "", "ab":
+		return game.NewEngine(game.EngineOptions{TTMB: aiTTMB, EndgameEmpties: aiEndgameEmpties, Evaluator: ev}), nil
+	case "mcts":
+		opts := game.MCTSOptions{Playouts: aiPlayouts}
+		if ev != nil {
+			opts.Value = game.EvaluatorValue(ev)
+		}
+		return game.NewMCTS(opts), nil
+	}
+	return nil, fmt.Errorf("未知的 AI 类型 %q（可选 ab / mcts）", kind)
+}
+
+// NewGameScreen 构造并初始化游戏界面；aiKind 见 newAI，evalSpec 见 game.NewEvaluator（空为默认）
+func NewGameScreen(ctx *audio.Context, aiEnabled, showScores bool, aiKind, evalSpec string) (*GameScreen, error) {
 	var err error
 	gs := &GameScreen{
 		state:       game.NewGameState(BoardRadius),
@@ -141,6 +170,20 @@ func NewGameScreen(ctx *audio.Context, aiEnabled, showScores bool) (*GameScreen,
 		ui:          UIState{}, // 初始化 UIState
 		fontFace:    basicfont.Face7x13,
 	}
+	var ev game.Evaluator
+	if evalSpec != "" {
+		if ev, err = game.NewEvaluator(evalSpec); err != nil {
+			return nil, err
+		}
+	}
+	if aiEnabled {
+		if gs.engine, err = newAI(aiKind, ev); err != nil {
+			return nil, err
+		}
+	}
+	if showScores {
+		gs.tipEngine = game.NewEngine(game.EngineOptions{TTMB: 1, Evaluator: ev})
+	}
 
 	// 加载贴图
 	if gs.tileImage, err = assets.LoadImage("hex_space"); err != nil {
@@ -330,12 +373,22 @@ func (gs *GameScreen) Update() error {
 		if gs.isAnimating || time.Now().Before(gs.aiDelayUntil) {
 			return nil
 		}
-		if move, _, ok := game.IterativeDeepening(gs.state.Board, game.PlayerB, depth); ok {
-			if total, err := gs.performMove(move, game.PlayerB); err == nil {
-				gs.aiDelayUntil = time.Now().Add(total)
+		if gs.aiResult == nil {
+			gs.startAISearch()
+			return nil
+		}
+		select {
+		case res := <-gs.aiResult:
+			gs.aiResult = nil
+			if res.OK {
+				if total, err := gs.performMove(res.Move, game.PlayerB); err == nil {
+					gs.aiDelayUntil = time.Now().Add(total)
+				}
 			}
+			gs.selected = nil
+		default:
+			// 还在思考，下一帧再来取
 		}
-		gs.selected = nil
 		return nil
 	}
 
@@ -345,6 +398,19 @@ func (gs *GameScreen) Update() error {
 	return nil
 }
 
+// startAISearch 在后台 goroutine 里搜索棋盘副本，Update 每帧轮询结果，窗口不再卡住
+func (gs *GameScreen) startAISearch() {
+	board := gs.state.Board.Clone()
+	ch := make(chan game.SearchResult, 1)
+	gs.aiResult = ch
+	go func() {
+		ch <- gs.engine.Search(context.Background(), board, game.PlayerB, game.SearchLimits{
+			MaxDepth: depth,
+			MoveTime: aiMoveTime,
+		})
+	}()
+}
+
 // Draw 每帧渲染：先清空背景，再绘制棋盘与棋子
 func (gs *GameScreen) Draw(screen *ebiten.Image) {
 	// 1) 清空屏幕背景（window 上）
@@ -387,6 +453,16 @@ func (gs *GameScreen) Draw(screen *ebiten.Image) {
 			// 4) 画字（-10, +4 是为了让文本大致居中）
 			text.Draw(gs.offscreen, str, fontFace, int(px)-10, int(py)+4, clr)
 		}
+
+		// 鼠标停在某个打了分的终点上：左上角列出该走法的静态评估拆解
+		mx, my := ebiten.CursorPosition()
+		if to, ok := pixelToAxial(float64(mx), float64(my), gs.state.Board, gs.tileImage); ok {
+			if ex, ok := gs.ui.Explain[to]; ok {
+				for i, line := range strings.Split(strings.TrimRight(ex.String(), "\n"), "\n") {
+					text.Draw(gs.offscreen, line, fontFace, 8, 16+i*14, color.White)
+				}
+			}
+		}
 	}
 	//fmt.Println(gs.anims)
 	for _, a := range gs.anims {
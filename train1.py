@@ -1,4 +1,5 @@
 #!/usr/bin/env python3
+import json
 import torch
 from torch.utils.data import TensorDataset, DataLoader
 import pandas as pd
@@ -27,6 +28,13 @@ for epoch in range(15):
 
 ws = model.weight.data.cpu().numpy().flatten()
 b  = model.bias.data.item()
+
+# 给 Go 侧 -eval linear:linear_eval.json 用，特征顺序见 game.LinearFeatureNames
+FEATURES = ["empty_ratio", "piece_diff", "outer_diff", "danger", "mobility",
+            "inf_diff", "holes", "opening_penalty", "early_jump", "last_move_bonus", "last_is_jump"]
+with open("linear_eval.json", "w") as f:
+    json.dump({"features": FEATURES, "weights": [float(w) for w in ws], "bias": b}, f, indent=2)
+print("写出 linear_eval.json")
 print("\n// Learned parameters, copy into your Go evaluate.go:")
 print("var learnedW = []float64{")
 for w in ws:
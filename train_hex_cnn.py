@@ -27,6 +27,27 @@ def build_geom_mask_81():
 
 GEOM_MASK_81 = build_geom_mask_81()  # 常量
 
+# 策略头：落点(81) × 方向(18)，方向 = To - From，顺序必须与 Go 侧 game.moveDirs 一致
+MOVE_DIRS = [(1, 0), (1, -1), (0, -1), (-1, 0), (-1, 1), (0, 1),            # 6 克隆
+             (2, 0), (2, -1), (2, -2), (1, -2), (0, -2), (-1, -1),          # 12 跳跃
+             (-2, 0), (-2, 1), (-2, 2), (-1, 2), (0, 2), (1, 1)]
+N_DIRS = len(MOVE_DIRS)
+POLICY_LEN = 81 * N_DIRS  # 1458
+
+def build_geom_mask_moves():
+    # 落点和起点都在棋盘内的走法=True
+    m = torch.zeros(POLICY_LEN, dtype=torch.bool)
+    for idx in range(81):
+        q, r = from_index(idx)
+        if not in_bounds(q, r):
+            continue
+        for k, (dq, dr) in enumerate(MOVE_DIRS):
+            if in_bounds(q - dq, r - dr):
+                m[idx * N_DIRS + k] = True
+    return m
+
+GEOM_MASK_MOVES = build_geom_mask_moves()
+
 def rot_axial(q, r, k):
     k %= 6
     if k == 0: return  q,        r
@@ -73,9 +94,17 @@ def build_permutations():
     # 保险检查（不依赖优化选项）
     if int(perms.min()) < 0 or int(perms.max()) >= 81:
         raise RuntimeError(f"perm out of range: [{int(perms.min())}, {int(perms.max())}]")
-    return torch.from_numpy(perms), torch.from_numpy(inv_perms)
 
-PERMS, INV_PERMS = build_permutations()
+    # 走法标签的置换：落点按格子置换，方向向量做同样的线性变换（旋转/镜像都是线性的）
+    move_perms = np.empty((12, POLICY_LEN), dtype=np.int64)
+    for a, f in enumerate(Fs):
+        dir_perm = [MOVE_DIRS.index(tuple(f(dq, dr))) for dq, dr in MOVE_DIRS]
+        for idx in range(81):
+            for k in range(N_DIRS):
+                move_perms[a, idx * N_DIRS + k] = perms[a, idx] * N_DIRS + dir_perm[k]
+    return torch.from_numpy(perms), torch.from_numpy(inv_perms), torch.from_numpy(move_perms)
+
+PERMS, INV_PERMS, MOVE_PERMS = build_permutations()
 
 # ====== 模型 ======
 class ResidualBlock(nn.Module):
@@ -93,7 +122,7 @@ class HexResNet(nn.Module):
         self.stem = nn.Sequential(nn.Conv2d(3, ch, 3, 1, 1, bias=False), nn.BatchNorm2d(ch), nn.ReLU(inplace=True))
         self.body = nn.Sequential(*[ResidualBlock(ch) for _ in range(blocks)])
         self.head_p = nn.Sequential(nn.Conv2d(ch, 32, 1), nn.ReLU(inplace=True),
-                                    nn.Flatten(), nn.Linear(32*9*9, 81))
+                                    nn.Flatten(), nn.Linear(32*9*9, POLICY_LEN))
         self.head_v = nn.Sequential(nn.Conv2d(ch, 32, 1), nn.ReLU(inplace=True),
                                     nn.AdaptiveAvgPool2d(1), nn.Flatten(),
                                     nn.Linear(32, 1), nn.Tanh())
@@ -123,14 +152,20 @@ def all_reduce_mean(x: torch.Tensor):
 
 # ====== 预计算 12× 增广并常驻内存 ======
 def load_csv_aug12(csv_path: str, store_dtype="uint8"):
-    # 读取：0..242 特征，243 move，244 z，(可选) 245 gameID
+    # 读取：0..242 特征，243 move（落点*18+方向，见 MOVE_DIRS），244 z，(可选) 245 gameID
+    # move 最大到 81*18-1，int8 装不下，单独给 int16；gameID 交给 pandas 自己推断
+    dtypes = {i: "int8" for i in range(243)}
+    dtypes[243] = "int16"
+    dtypes[244] = "int8"
     try:
-        df = pd.read_csv(csv_path, header=None, dtype="int8", engine="pyarrow")
+        df = pd.read_csv(csv_path, header=None, dtype=dtypes, engine="pyarrow")
     except Exception:
-        df = pd.read_csv(csv_path, header=None, dtype="int8")
+        df = pd.read_csv(csv_path, header=None, dtype=dtypes)
 
     X = torch.from_numpy(df.iloc[:, :243].values)  # int8 {0,1}
     move = torch.from_numpy(df.iloc[:, 243].values.astype(np.int64))
+    if int(move.max()) < 81 and len(move) > 1000:
+        print("[warn] move 标签全部 < 81，像是旧版只记落点的数据集")
     z = torch.from_numpy(df.iloc[:, 244].values.astype(np.float32)).view(-1, 1)
     del df  # 释放 DataFrame
 
@@ -145,7 +180,7 @@ def load_csv_aug12(csv_path: str, store_dtype="uint8"):
         invp = INV_PERMS[a]  # (81,)
         Xa = X.index_select(2, invp)  # (N,3,81)
         X_augs.append(Xa)
-        move_augs.append(PERMS[a][move])  # (N,)
+        move_augs.append(MOVE_PERMS[a][move])  # (N,)
 
     X_aug = torch.cat(X_augs, dim=0).reshape(-1, 3, 9, 9)   # (12N,3,9,9)
     move_aug = torch.cat(move_augs, dim=0)                  # (12N,)
@@ -181,8 +216,8 @@ class HexDatasetPrecomputed(Dataset):
 
 # ====== 训练 ======
 def get_mask(batch_size, device):
-    # (B,81) 的几何掩码：棋盘内=True，外框=False
-    return GEOM_MASK_81.to(device).unsqueeze(0).expand(batch_size, -1)
+    # (B,POLICY_LEN) 的几何掩码：起点、落点都在棋盘内=True
+    return GEOM_MASK_MOVES.to(device).unsqueeze(0).expand(batch_size, -1)
 
 def main():
     ap = argparse.ArgumentParser()
@@ -303,6 +338,8 @@ if __name__ == "__main__":
     # 还可检查可逆性
     for a in range(12):
         assert torch.equal(INV_PERMS[a][PERMS[a]], torch.arange(81))
+        # 合法走法变换后仍是合法走法
+        assert bool(GEOM_MASK_MOVES[MOVE_PERMS[a][GEOM_MASK_MOVES.nonzero().squeeze(1)]].all())
     main()
 
 # cp /home/qujing/gotest/dataset.csv /home/qujing/gotest/train1/dataset.csv